@@ -25,39 +25,91 @@ const docTemplate = `{
     "basePath": "{{.BasePath}}",
     "paths": {
         "/api-keys": {
-            "post": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a new API key. Requires an ` + "`" + `api` + "`" + ` type key.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns a paginated list of API keys with their label, expiry and last use. Keys themselves are never returned. Requires an ` + "`" + `api` + "`" + ` scope key; keys other than admin keys only see the keys they may manage.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "API Keys"
                 ],
-                "summary": "Create API key",
+                "summary": "List API keys",
                 "parameters": [
                     {
-                        "description": "API key information",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
-                        }
+                        "type": "string",
+                        "description": "Only keys whose label matches ('*' and '?' act as wildcards)",
+                        "name": "label",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only keys explicitly granted this scope",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only enabled or disabled keys",
+                        "name": "enabled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only expired or unexpired keys",
+                        "name": "expired",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only keys not used since this RFC 3339 time, including keys never used",
+                        "name": "unused_since",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "created_at",
+                            "last_used_at",
+                            "expires_at",
+                            "label"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 500)",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ApiKeyResponse"
+                            "$ref": "#/definitions/handlers.ApiKeyListResponse"
                         }
                     },
                     "400": {
@@ -94,39 +146,40 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api-keys/{key}": {
-            "delete": {
+            },
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Soft-disable an API key by setting its is_enabled flag to false.",
+                "description": "Create a new API key. The full key is only returned in this response; store it safely. Granting the ` + "`" + `admin` + "`" + ` scope, rate limits or a storage quota requires an ` + "`" + `admin` + "`" + ` key. Requires an ` + "`" + `api` + "`" + ` scope key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
                     "API Keys"
                 ],
-                "summary": "Disable API key",
+                "summary": "Create API key",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "API key value",
-                        "name": "key",
-                        "in": "path",
-                        "required": true
+                        "description": "API key information",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateAPIKeyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "message": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/handlers.ApiKeyResponse"
                         }
                     },
                     "400": {
@@ -151,8 +204,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -176,23 +229,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/api-keys/{key}/enable": {
-            "post": {
+        "/api-keys/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Enable an API key by setting its is_enabled flag to true.",
+                "description": "Soft-disable an API key by setting its is_enabled flag to false. Only admin keys can disable admin keys, and restricted keys can only disable keys within their own path restriction.",
                 "tags": [
                     "API Keys"
                 ],
-                "summary": "Enable API key",
+                "summary": "Disable API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "API key value",
-                        "name": "key",
+                        "description": "API key ID or prefix",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -231,6 +284,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -242,8 +306,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -252,64 +316,63 @@ const docTemplate = `{
                                 }
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/health": {
-            "get": {
-                "description": "Show the status of server.",
-                "consumes": [
-                    "*/*"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "Health"
-                ],
-                "summary": "Health Check",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
-                            "additionalProperties": true
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
                         }
                     }
                 }
             }
         },
-        "/s/{shortcode}": {
-            "get": {
-                "description": "Downloads a file using a short code. If the original file is private, a 'download' type token is required.",
-                "produces": [
-                    "application/octet-stream"
+        "/api-keys/{id}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Enable an API key by setting its is_enabled flag to true. Only admin keys can enable admin keys, and restricted keys can only enable keys within their own path restriction.",
                 "tags": [
-                    "Short Links"
+                    "API Keys"
                 ],
-                "summary": "Download a file from a short link",
+                "summary": "Enable API key",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Short code of the file",
-                        "name": "shortcode",
+                        "description": "API key ID or prefix",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Authentication token for private files",
-                        "name": "token",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "The requested file",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
                         }
                     },
                     "401": {
@@ -323,6 +386,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -334,6 +408,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -348,45 +433,75 @@ const docTemplate = `{
                 }
             }
         },
-        "/shorten": {
-            "post": {
+        "/api/audit": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Creates a short link for an existing file. Requires a 'shorten' type token.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Returns audit entries for uploads, private downloads, deletions, signed URLs and short link and API key management, newest first. Requires an ` + "`" + `admin` + "`" + ` key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Short Links"
+                    "Audit"
                 ],
-                "summary": "Create a short link",
+                "summary": "Query the audit log",
                 "parameters": [
                     {
-                        "description": "Request body containing the filename",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/handlers.CreateShortLinkRequest"
-                        }
+                        "type": "string",
+                        "description": "Only entries at or after this time, RFC 3339",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries before this time, RFC 3339",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only entries of this API key ID",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated actions, e.g. file.upload,file.download",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "success",
+                            "denied",
+                            "failure"
+                        ],
+                        "type": "string",
+                        "description": "Only entries with this outcome",
+                        "name": "outcome",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 500)",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "short_url_path": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/handlers.AuditLogListResponse"
                         }
                     },
                     "400": {
@@ -411,17 +526,6 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "properties": {
-                                "error": {
-                                    "type": "string"
-                                }
-                            }
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -436,41 +540,112 @@ const docTemplate = `{
                 }
             }
         },
-        "/shorten/{shortcode}": {
-            "delete": {
+        "/api/files": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Soft-disable a short link by setting is_enabled to false",
+                "description": "Returns a paginated list of stored files with the short links pointing to each. Requires a 'list' type token.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Short Links"
+                    "Files"
                 ],
-                "summary": "Disable short link",
+                "summary": "List files",
                 "parameters": [
                     {
+                        "enum": [
+                            "public",
+                            "private"
+                        ],
                         "type": "string",
-                        "description": "Short code of the file",
-                        "name": "shortcode",
-                        "in": "path",
-                        "required": true
+                        "description": "Only files in this directory",
+                        "name": "visibility",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only names starting with this prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only names matching this glob ('*' and '?')",
+                        "name": "glob",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only files created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Minimum size in bytes",
+                        "name": "min_size",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum size in bytes",
+                        "name": "max_size",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "size",
+                            "created_at",
+                            "updated_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 500)",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "message": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/handlers.FileListResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -480,8 +655,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -505,25 +680,49 @@ const docTemplate = `{
                 }
             }
         },
-        "/shorten/{shortcode}/enable": {
-            "post": {
+        "/api/files/{name}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Reactivate a short link by setting is_enabled to true",
+                "description": "Deletes a stored file and disables (default) or deletes every short link pointing to it. Requires a 'delete' type token.",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Short Links"
+                    "Files"
                 ],
-                "summary": "Enable short link",
+                "summary": "Delete a file",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Short code of the file",
-                        "name": "shortcode",
+                        "description": "Filename",
+                        "name": "name",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "public",
+                            "private"
+                        ],
+                        "type": "string",
+                        "description": "Directory the file lives in",
+                        "name": "visibility",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "disable",
+                            "delete"
+                        ],
+                        "type": "string",
+                        "description": "What to do with short links (default disable)",
+                        "name": "cascade",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -532,8 +731,28 @@ const docTemplate = `{
                         "schema": {
                             "type": "object",
                             "properties": {
+                                "cascade": {
+                                    "type": "string"
+                                },
+                                "file": {
+                                    "$ref": "#/definitions/handlers.FileResponse"
+                                },
                                 "message": {
                                     "type": "string"
+                                },
+                                "short_links_affected": {
+                                    "type": "integer"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
                                 }
                             }
                         }
@@ -549,6 +768,17 @@ const docTemplate = `{
                             }
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
                     "404": {
                         "description": "Not Found",
                         "schema": {
@@ -574,53 +804,91 @@ const docTemplate = `{
                 }
             }
         },
-        "/upload": {
-            "post": {
+        "/api/shortlinks": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Uploads a file to either the public or private directory. Requires an 'upload' type token.",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Returns a paginated list of short links. Requires a 'shorten' type token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Short Links"
                 ],
-                "summary": "Upload a file",
+                "summary": "List short links",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "File to upload",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
+                        "type": "string",
+                        "description": "Only links to files with this name ('*' and '?' act as wildcards)",
+                        "name": "filename",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only enabled or disabled links",
+                        "name": "enabled",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Only links created with this API key ID",
+                        "name": "created_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created at or after this RFC 3339 time",
+                        "name": "created_after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only links created before this RFC 3339 time",
+                        "name": "created_before",
+                        "in": "query"
                     },
                     {
                         "enum": [
-                            "public",
-                            "private"
+                            "short_code",
+                            "created_at",
+                            "download_count"
                         ],
                         "type": "string",
-                        "description": "Target directory: 'public' or 'private' (default)",
-                        "name": "X-GoFi-Target-Dir",
-                        "in": "header"
+                        "description": "Sort field (default created_at)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 500)",
+                        "name": "page_size",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "properties": {
-                                "download_path": {
-                                    "type": "string"
-                                }
-                            }
+                            "$ref": "#/definitions/handlers.ShortLinkListResponse"
                         }
                     },
                     "400": {
@@ -659,23 +927,65 @@ const docTemplate = `{
                 }
             }
         },
-        "/uuid": {
+        "/api/shortlinks/{code}": {
             "get": {
-                "description": "Return a random UUIDv4",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the details of a short link. Requires a 'shorten' type token.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Utility"
+                    "Short Links"
+                ],
+                "summary": "Get short link details",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Generate a random UUIDv4",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShortLinkResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
                             "type": "object",
                             "properties": {
-                                "uuid": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
                                     "type": "string"
                                 }
                             }
@@ -693,47 +1003,51 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/{filename}": {
-            "get": {
+            },
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Downloads a file. Public files are accessible directly. For private files, a 'download' type token is required via query parameter or Authorization header.",
+                "description": "Repoints a short link at another file and/or changes its visibility. Requires a 'shorten' type token; making a private file public through the link also requires the 'download' scope.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
-                    "application/octet-stream"
+                    "application/json"
                 ],
                 "tags": [
-                    "Files"
+                    "Short Links"
                 ],
-                "summary": "Download a file",
+                "summary": "Update a short link",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Filename",
-                        "name": "filename",
+                        "description": "Short code",
+                        "name": "code",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Authentication token for private files",
-                        "name": "token",
-                        "in": "query"
+                        "description": "Fields to change",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UpdateShortLinkRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "The requested file",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/handlers.ShortLinkResponse"
                         }
                     },
-                    "401": {
-                        "description": "Unauthorized",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "properties": {
@@ -743,9 +1057,1653 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "403": {
-                        "description": "Forbidden",
-                        "schema": {
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/shortlinks/{code}/stats": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns visit totals and per-day aggregates (UTC) of a short link. Requires a 'shorten' type token.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Short link statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Start of the period, RFC 3339 (default 30 days ago)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "End of the period, RFC 3339 (default now)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ShortLinkStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/signed-urls": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Issues a time-limited URL for a private file that works without an API key. The URL is signed with the server secret and optionally bound to a client IP. Requires a 'download' type token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "Issue a signed download URL",
+                "parameters": [
+                    {
+                        "description": "File and lifetime",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateSignedURLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "expires_at": {
+                                    "type": "string"
+                                },
+                                "url_path": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/api/usage": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the bytes stored overall and per API key (counting the files each key uploaded) together with the quotas. Requires an ` + "`" + `admin` + "`" + ` scope key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "API Keys"
+                ],
+                "summary": "Get storage usage",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Page number, starting at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Items per page (default 50, max 500)",
+                        "name": "page_size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.UsageResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Show the status of server.",
+                "consumes": [
+                    "*/*"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Health"
+                ],
+                "summary": "Health Check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/s/{shortcode}": {
+            "get": {
+                "description": "Downloads a file using a short code. If the link has a password, it must be given via the X-GoFi-Link-Password header or the unlock cookie (browsers get an HTML form); otherwise a private link requires a 'download' type token. Expired or used-up links answer 410 Gone. HEAD requests and failed downloads do not count against max_downloads.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Download a file from a short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code of the file",
+                        "name": "shortcode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authentication token for private files",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of a password-protected link",
+                        "name": "X-GoFi-Link-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The requested file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Checks the submitted password and sets a short-lived signed cookie, then redirects back to the short link.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "text/html"
+                ],
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Unlock a password-protected short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code of the file",
+                        "name": "shortcode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Short link password",
+                        "name": "password",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "303": {
+                        "description": "See Other"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "description": "Downloads a file using a short code. If the link has a password, it must be given via the X-GoFi-Link-Password header or the unlock cookie (browsers get an HTML form); otherwise a private link requires a 'download' type token. Expired or used-up links answer 410 Gone. HEAD requests and failed downloads do not count against max_downloads.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Download a file from a short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code of the file",
+                        "name": "shortcode",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authentication token for private files",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Password of a password-protected link",
+                        "name": "X-GoFi-Link-Password",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The requested file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "410": {
+                        "description": "Gone",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shorten": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a short link for an existing file. Requires a 'shorten' type token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Create a short link",
+                "parameters": [
+                    {
+                        "description": "Request body containing the filename",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.CreateShortLinkRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "expires_at": {
+                                    "type": "string"
+                                },
+                                "max_downloads": {
+                                    "type": "integer"
+                                },
+                                "short_url_path": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shorten/{shortcode}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Soft-disable a short link by setting is_enabled to false",
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Disable short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code of the file",
+                        "name": "shortcode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/shorten/{shortcode}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Reactivate a short link by setting is_enabled to true",
+                "tags": [
+                    "Short Links"
+                ],
+                "summary": "Enable short link",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Short code of the file",
+                        "name": "shortcode",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "message": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Uploads a file to either the public or private directory. Requires an 'upload' type token.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "Upload a file",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "File to upload",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "public",
+                            "private"
+                        ],
+                        "type": "string",
+                        "description": "Target directory: 'public' or 'private' (default)",
+                        "name": "X-GoFi-Target-Dir",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "reject",
+                            "overwrite",
+                            "rename",
+                            "version"
+                        ],
+                        "type": "string",
+                        "description": "What to do if the name is taken (defaults to UPLOAD_CONFLICT_POLICY); also accepted as form field 'on_conflict'",
+                        "name": "X-GoFi-On-Conflict",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "numeric",
+                            "random"
+                        ],
+                        "type": "string",
+                        "description": "Suffix style for 'rename' (defaults to UPLOAD_RENAME_STYLE); also accepted as form field 'rename_style'",
+                        "name": "X-GoFi-Rename-Style",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "archived_as": {
+                                    "type": "string"
+                                },
+                                "download_path": {
+                                    "type": "string"
+                                },
+                                "file": {
+                                    "$ref": "#/definitions/handlers.FileResponse"
+                                },
+                                "filename": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Creates a tus upload. ` + "`" + `Upload-Metadata` + "`" + ` must contain ` + "`" + `filename` + "`" + ` (or ` + "`" + `name` + "`" + `) and may contain ` + "`" + `visibility` + "`" + ` (` + "`" + `public` + "`" + ` or ` + "`" + `private` + "`" + `, default ` + "`" + `private` + "`" + `). Requires an 'upload' type token.",
+                "tags": [
+                    "Resumable Uploads"
+                ],
+                "summary": "Create a resumable upload",
+                "parameters": [
+                    {
+                        "enum": [
+                            "1.0.0"
+                        ],
+                        "type": "string",
+                        "description": "Protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Total size of the upload in bytes",
+                        "name": "Upload-Length",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated key/base64-value pairs",
+                        "name": "Upload-Metadata",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "public",
+                            "private"
+                        ],
+                        "type": "string",
+                        "description": "Target directory when not given in metadata",
+                        "name": "X-GoFi-Target-Dir",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "reject",
+                            "overwrite",
+                            "rename",
+                            "version"
+                        ],
+                        "type": "string",
+                        "description": "What to do if the name is taken when the upload completes; also accepted as metadata 'on_conflict'",
+                        "name": "X-GoFi-On-Conflict",
+                        "in": "header"
+                    },
+                    {
+                        "enum": [
+                            "numeric",
+                            "random"
+                        ],
+                        "type": "string",
+                        "description": "Suffix style for 'rename'; also accepted as metadata 'rename_style'",
+                        "name": "X-GoFi-Rename-Style",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "headers": {
+                            "Location": {
+                                "type": "string",
+                                "description": "URL of the created upload"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "412": {
+                        "description": "Precondition Failed",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "options": {
+                "description": "Returns the supported tus version, extensions, checksum algorithms and maximum upload size.",
+                "tags": [
+                    "Resumable Uploads"
+                ],
+                "summary": "Discover tus capabilities",
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Tus-Checksum-Algorithm": {
+                                "type": "string",
+                                "description": "Supported checksum algorithms"
+                            },
+                            "Tus-Extension": {
+                                "type": "string",
+                                "description": "Supported extensions"
+                            },
+                            "Tus-Max-Size": {
+                                "type": "integer",
+                                "description": "Maximum upload size in bytes, if limited"
+                            },
+                            "Tus-Version": {
+                                "type": "string",
+                                "description": "Supported protocol versions"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uploads/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Discards an unfinished upload and its received data. Requires an 'upload' type token; only the key that created the upload can access it.",
+                "tags": [
+                    "Resumable Uploads"
+                ],
+                "summary": "Terminate a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "1.0.0"
+                        ],
+                        "type": "string",
+                        "description": "Protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            },
+            "head": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns how many bytes of the upload the server has received. Requires an 'upload' type token; only the key that created the upload can access it.",
+                "tags": [
+                    "Resumable Uploads"
+                ],
+                "summary": "Get resumable upload offset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "1.0.0"
+                        ],
+                        "type": "string",
+                        "description": "Protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "headers": {
+                            "Upload-Length": {
+                                "type": "integer",
+                                "description": "Total size of the upload"
+                            },
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "Bytes received so far"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Appends the request body at ` + "`" + `Upload-Offset` + "`" + `. When the last byte arrives the file is stored in its target directory. An optional ` + "`" + `Upload-Checksum` + "`" + ` verifies the chunk. Requires an 'upload' type token; only the key that created the upload can access it.",
+                "consumes": [
+                    "application/offset+octet-stream"
+                ],
+                "tags": [
+                    "Resumable Uploads"
+                ],
+                "summary": "Append to a resumable upload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Upload ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "1.0.0"
+                        ],
+                        "type": "string",
+                        "description": "Protocol version",
+                        "name": "Tus-Resumable",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Offset the chunk starts at",
+                        "name": "Upload-Offset",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Chunk checksum: '\u003csha1|sha256|md5\u003e \u003cbase64 digest\u003e'",
+                        "name": "Upload-Checksum",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "headers": {
+                            "Upload-Offset": {
+                                "type": "integer",
+                                "description": "New offset"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "415": {
+                        "description": "Unsupported Media Type",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "460": {
+                        "description": "",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "507": {
+                        "description": "Insufficient Storage",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/uuid": {
+            "get": {
+                "description": "Return a random UUIDv4",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Utility"
+                ],
+                "summary": "Generate a random UUIDv4",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "uuid": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/{filename}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Downloads a file. Public files are accessible directly. For private files, a 'download' type token is required via query parameter or Authorization header, or a signed URL issued by POST /api/signed-urls.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "Files"
+                ],
+                "summary": "Download a file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filename",
+                        "name": "filename",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Authentication token for private files",
+                        "name": "token",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiry of a signed URL (Unix seconds)",
+                        "name": "exp",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature of a signed URL",
+                        "name": "sig",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Set to 'ip' when the signed URL is bound to a client IP",
+                        "name": "bind",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "The requested file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object",
+                            "properties": {
+                                "error": {
+                                    "type": "string"
+                                }
+                            }
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
                             "type": "object",
                             "properties": {
                                 "error": {
@@ -767,30 +2725,193 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ApiKeyResponse": {
+        }
+    },
+    "definitions": {
+        "handlers.ApiKeyListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ApiKeyResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ApiKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "is_expired": {
+                    "type": "boolean"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "last_used_ip": {
+                    "type": "string"
+                },
+                "name_pattern": {
+                    "description": "为空表示不限制文件名 / Empty means any filename",
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit_bandwidth": {
+                    "type": "integer"
+                },
+                "rate_limit_burst": {
+                    "type": "integer"
+                },
+                "rate_limit_requests": {
+                    "description": "为空表示使用默认限流 / Null means the default rate limit",
+                    "type": "number"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Scope"
+                    }
+                },
+                "storage_quota": {
+                    "description": "为空表示使用默认配额 / Null means the default quota",
+                    "type": "integer"
+                },
+                "visibility": {
+                    "description": "为空表示不限制区域 / Empty means any area",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AuditLogListResponse": {
             "type": "object",
             "properties": {
-                "is_enabled": {
-                    "type": "boolean"
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.AuditLogResponse"
+                    }
                 },
-                "key": {
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AuditLogResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
                     "type": "string"
                 },
-                "type": {
-                    "$ref": "#/definitions/models.ApiKeyType"
+                "actor_key_id": {
+                    "description": "为空表示未携带有效密钥 / Null when no known key was presented",
+                    "type": "integer"
+                },
+                "client_ip": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "outcome": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "target": {
+                    "type": "string"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
                 }
             }
         },
         "handlers.CreateAPIKeyRequest": {
             "type": "object",
-            "required": [
-                "type"
-            ],
             "properties": {
+                "expires_at": {
+                    "description": "RFC 3339 过期时间 / RFC 3339 expiry time",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "相对过期时间，如 \"720h\" / Relative expiry, e.g. \"720h\"",
+                    "type": "string"
+                },
+                "label": {
+                    "description": "用途或持有人 / Purpose or owner",
+                    "type": "string"
+                },
+                "name_pattern": {
+                    "description": "文件名 glob，如 \"team-a-*\" / Filename glob, e.g. \"team-a-*\"",
+                    "type": "string"
+                },
+                "rate_limit_bandwidth": {
+                    "description": "每秒字节数 / Bytes per second",
+                    "type": "integer"
+                },
+                "rate_limit_burst": {
+                    "description": "突发请求数 / Request burst",
+                    "type": "integer"
+                },
+                "rate_limit_requests": {
+                    "description": "限流覆盖，为空时使用配置中的默认值，0 表示不限制 / Rate limit overrides, null uses the configured default, 0 means unlimited",
+                    "type": "number"
+                },
+                "scopes": {
+                    "description": "权限范围 / Scopes",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "storage_quota": {
+                    "description": "存储配额（字节），为空时使用配置中的默认值，0 表示不限制 / Storage quota in bytes, null uses the configured default, 0 means unlimited",
+                    "type": "integer"
+                },
                 "type": {
+                    "description": "已弃用：单一权限范围 / Deprecated: a single scope",
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "路径限制，为空表示不限制 / Path restriction, empty means unrestricted",
                     "type": "string"
                 }
             }
@@ -801,24 +2922,392 @@ const docTemplate = `{
                 "filename"
             ],
             "properties": {
+                "alias": {
+                    "description": "自定义短代码 / Custom short code",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "RFC 3339 过期时间 / RFC 3339 expiry time",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "相对过期时间，如 \"24h\" / Relative expiry, e.g. \"24h\"",
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                },
+                "max_downloads": {
+                    "description": "最大下载次数 / Maximum number of downloads",
+                    "type": "integer"
+                },
+                "password": {
+                    "description": "访问密码 / Access password",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.CreateSignedURLRequest": {
+            "type": "object",
+            "required": [
+                "filename"
+            ],
+            "properties": {
+                "bind_ip": {
+                    "description": "只允许指定 IP 使用 / Only usable from one IP",
+                    "type": "boolean"
+                },
+                "client_ip": {
+                    "description": "绑定的 IP，默认为请求方 IP / IP to bind, defaults to the requester's",
+                    "type": "string"
+                },
+                "expires_in": {
+                    "description": "有效期，如 \"30m\"，默认 1h / Lifetime such as \"30m\", default 1h",
+                    "type": "string"
+                },
+                "filename": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FileListItem": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "original_name": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "short_links": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FileShortLink"
+                    }
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FileListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.FileListItem"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.FileResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "mime_type": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "original_name": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.FileShortLink": {
+            "type": "object",
+            "properties": {
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "short_code": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.KeyUsage": {
+            "type": "object",
+            "properties": {
+                "file_count": {
+                    "type": "integer"
+                },
+                "key_id": {
+                    "type": "integer"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "quota_bytes": {
+                    "description": "为空表示不限制 / Null means unlimited",
+                    "type": "integer"
+                },
+                "used_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ShortLinkDailyStats": {
+            "type": "object",
+            "properties": {
+                "bytes_served": {
+                    "type": "integer"
+                },
+                "date": {
+                    "type": "string"
+                },
+                "downloads": {
+                    "type": "integer"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "unique_ips": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ShortLinkListResponse": {
+            "type": "object",
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ShortLinkResponse"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.ShortLinkResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "created_by_key_id": {
+                    "type": "integer"
+                },
+                "download_count": {
+                    "type": "integer"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "file": {
+                    "description": "文件已被删除时为空 / Null when the file was deleted",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/handlers.FileResponse"
+                        }
+                    ]
+                },
+                "has_password": {
+                    "type": "boolean"
+                },
+                "is_enabled": {
+                    "type": "boolean"
+                },
+                "max_downloads": {
+                    "type": "integer"
+                },
+                "original_filename": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "short_url_path": {
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "实际生效的可见性 / Effective visibility",
+                    "type": "string"
+                },
+                "visibility_override": {
+                    "description": "为空表示沿用文件 / Empty means inherited from the file",
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ShortLinkStatsResponse": {
+            "type": "object",
+            "properties": {
+                "bytes_served": {
+                    "type": "integer"
+                },
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.ShortLinkDailyStats"
+                    }
+                },
+                "download_count": {
+                    "description": "创建以来的下载次数 / Downloads since creation",
+                    "type": "integer"
+                },
+                "downloads": {
+                    "description": "成功（2xx）的访问 / Successful (2xx) visits",
+                    "type": "integer"
+                },
+                "first_hit_at": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "hits": {
+                    "type": "integer"
+                },
+                "last_hit_at": {
+                    "type": "string"
+                },
+                "short_code": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                },
+                "unique_ips": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.UpdateShortLinkRequest": {
+            "type": "object",
+            "properties": {
+                "file_visibility": {
+                    "description": "在哪个区域查找该文件，默认优先 private / Area to look the file up in, private first by default",
+                    "type": "string"
+                },
                 "filename": {
+                    "description": "重新指向的文件 / File to repoint the link at",
+                    "type": "string"
+                },
+                "visibility": {
+                    "description": "public、private 或 inherit / public, private or inherit",
                     "type": "string"
                 }
             }
         },
-        "models.ApiKeyType": {
+        "handlers.UsageResponse": {
+            "type": "object",
+            "properties": {
+                "file_count": {
+                    "type": "integer"
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.KeyUsage"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "quota_bytes": {
+                    "description": "全局上限，为空表示不限制 / Global cap, null means unlimited",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "used_bytes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.Scope": {
             "type": "string",
             "enum": [
                 "upload",
                 "download",
                 "shorten",
-                "api"
+                "api",
+                "list",
+                "delete",
+                "admin"
+            ],
+            "x-enum-comments": {
+                "ScopeAPI": "管理 API Key / Manage API keys",
+                "ScopeAdmin": "包含所有权限 / Implies every scope"
+            },
+            "x-enum-descriptions": [
+                "",
+                "",
+                "",
+                "管理 API Key / Manage API keys",
+                "",
+                "",
+                "包含所有权限 / Implies every scope"
             ],
             "x-enum-varnames": [
-                "ApiKeyTypeUpload",
-                "ApiKeyTypeDownload",
-                "ApiKeyTypeShorten",
-                "ApiKeyTypeAPI"
+                "ScopeUpload",
+                "ScopeDownload",
+                "ScopeShorten",
+                "ScopeAPI",
+                "ScopeList",
+                "ScopeDelete",
+                "ScopeAdmin"
             ]
         }
     },
@@ -1,13 +1,20 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/ShinoharaHaruna/GoFi/internal/analytics"
 	"github.com/ShinoharaHaruna/GoFi/internal/config"
 	"github.com/ShinoharaHaruna/GoFi/internal/database"
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
 	"github.com/ShinoharaHaruna/GoFi/internal/router"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
 	"github.com/gin-gonic/gin"
 )
 
@@ -47,27 +54,109 @@ func main() {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// 执行子命令（gofi migrate up|down|status）而不是启动服务器
+	// Run a subcommand (gofi migrate up|down|status) instead of starting the server
+	if args := flag.Args(); len(args) > 0 {
+		if args[0] != "migrate" {
+			log.Fatalf("Unknown command %q; the only command is migrate", args[0])
+		}
+		if err := runMigrate(cfg, args[1:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+
+	// 未配置密钥时随机生成一个，重启后已签发的 Cookie 和 URL 将失效
+	// Generate a random secret when none is configured; issued cookies and URLs stop working after a restart
+	if cfg.SecretKey == "" {
+		cfg.SecretKey, err = utility.GenerateRandomString(32)
+		if err != nil {
+			log.Fatalf("Failed to generate secret key: %v", err)
+		}
+		log.Printf("Warning: SECRET_KEY is not set; using a random key, signed cookies and URLs will not survive a restart")
+	}
+
+	// 校验短链接相关配置
+	// Validate the short link configuration
+	if _, err := utility.ResolveAlphabet(cfg.ShortCodeAlphabet); err != nil {
+		log.Fatalf("Invalid SHORT_CODE_ALPHABET: %v", err)
+	}
+	if cfg.ShortCodeLength < utility.MinShortCodeLength || cfg.ShortCodeLength > utility.MaxShortCodeLength {
+		log.Fatalf("Invalid SHORT_CODE_LENGTH: must be between %d and %d", utility.MinShortCodeLength, utility.MaxShortCodeLength)
+	}
+	if !analytics.ValidIPMode(cfg.ShortLinkHitIPMode) {
+		log.Fatalf("Invalid SHORT_LINK_HIT_IP_MODE %q: must be full, truncate or none", cfg.ShortLinkHitIPMode)
+	}
+
 	// 初始化数据库
 	// Initialize database
-	if err := database.InitDB(cfg); err != nil {
+	db, err := database.InitDB(cfg)
+	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// 初始化存储后端
+	// Initialize storage backend
+	store, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage backend: %v", err)
+	}
+
+	// 创建持有全部依赖的服务器，并启动短链接访问记录器和审计记录器
+	// Create the server holding every dependency, starting the short link hit recorder and the audit logger
+	srv, err := handlers.NewServer(cfg, db, store)
+	if err != nil {
+		log.Fatalf("Failed to initialize server: %v", err)
+	}
+
+	// 为已存储但尚无记录的文件补建元数据
+	// Backfill metadata for stored files that have no record yet
+	if err := utility.BackfillFileRecords(context.Background(), store, srv.Files, srv.ShortLinks); err != nil {
+		log.Fatalf("Failed to backfill file records: %v", err)
+	}
+
 	// 设置 Gin 模式
 	// Set Gin mode
 	gin.SetMode(cfg.GinMode)
 
-	// 创建路由
-	// Create router
-	r := router.SetupRouter(cfg)
+	// 启动服务器，直到收到 SIGINT 或 SIGTERM
+	// Start the server and run until SIGINT or SIGTERM
+	httpServer, err := router.NewHTTPServer(srv)
+	if err != nil {
+		log.Fatalf("Failed to set up router: %v", err)
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server starting on %s", httpServer.Addr)
+		serveErr <- httpServer.ListenAndServe()
+	}()
 
-	// 启动服务器
-	// Start server
-	listenAddr := fmt.Sprintf(":%s", cfg.GoFiPort)
-	log.Printf("Server starting on %s", listenAddr)
-	if err := r.Run(listenAddr); err != nil {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-serveErr:
 		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
+	}
+	stop() // 再次收到信号时立即退出 / A second signal exits immediately
+
+	// 停止接受新连接，并等待进行中的上传和下载完成；超过宽限期后强制关闭剩余连接
+	// Stop accepting connections and wait for in-flight uploads and downloads; past the grace period the rest are closed
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", cfg.ShutdownGracePeriod)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownGracePeriod)
+	defer cancel()
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Grace period over, closing remaining connections: %v", err)
+		httpServer.Close()
+	}
+
+	// 写入剩余的访问记录和审计记录，然后关闭数据库连接池
+	// Flush the remaining hits and audit entries, then close the database pool
+	srv.Close()
+	if err := database.Close(db); err != nil {
+		log.Printf("Failed to close database: %v", err)
 	}
+	log.Println("Server stopped")
 }
 
 // parseFlags 解析命令行参数，返回配置文件路径（可为空）
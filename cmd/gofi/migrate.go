@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/database"
+)
+
+// migrateUsage 是 migrate 子命令的用法说明
+// migrateUsage describes how to use the migrate subcommand
+const migrateUsage = `usage: gofi [-c config.toml] migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down [N]   roll back the last N applied migrations (default 1)
+  status     list migrations and whether they have been applied`
+
+// runMigrate 执行 migrate 子命令
+// runMigrate runs the migrate subcommand
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	// 1. 解析子命令参数，避免在连接数据库之后才发现用法错误
+	// 1. Parse the subcommand arguments, so usage errors surface before connecting to the database
+	steps := 1
+	switch args[0] {
+	case "up", "status":
+		if len(args) > 1 {
+			return errors.New(migrateUsage)
+		}
+	case "down":
+		if len(args) > 2 {
+			return errors.New(migrateUsage)
+		}
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid number of migrations %q: must be a positive integer", args[1])
+			}
+			steps = n
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	// 2. 连接数据库，但不自动迁移
+	// 2. Connect to the database without migrating automatically
+	db, err := database.Connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer database.Close(db)
+
+	// 3. 执行子命令
+	// 3. Run the subcommand
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, m := range applied {
+			fmt.Printf("Applied %d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database schema is already up to date.")
+		}
+	case "down":
+		reverted, err := database.MigrateDown(db, steps)
+		for _, m := range reverted {
+			fmt.Printf("Rolled back %d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Println("No applied migrations to roll back.")
+		}
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, state := range states {
+			appliedAt := "pending"
+			if state.AppliedAt != nil {
+				appliedAt = state.AppliedAt.UTC().Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", state.Version, state.Name, appliedAt)
+		}
+		return w.Flush()
+	}
+	return nil
+}
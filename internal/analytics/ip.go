@@ -0,0 +1,48 @@
+package analytics
+
+import "net/netip"
+
+// 客户端 IP 的记录方式
+// How client IPs are recorded
+const (
+	IPModeFull     = "full"     // 完整记录 / Record the full address
+	IPModeTruncate = "truncate" // IPv4 保留 /24，IPv6 保留 /48 / Keep the /24 of IPv4 and the /48 of IPv6
+	IPModeNone     = "none"     // 不记录 / Do not record
+)
+
+// ValidIPMode 判断 mode 是否为受支持的 IP 记录方式
+// ValidIPMode reports whether mode is a supported IP recording mode
+func ValidIPMode(mode string) bool {
+	switch mode {
+	case IPModeFull, IPModeTruncate, IPModeNone:
+		return true
+	default:
+		return false
+	}
+}
+
+// AnonymizeIP 按 mode 处理客户端 IP，无法解析的地址在截断模式下记录为空
+// AnonymizeIP applies mode to a client IP; unparsable addresses are recorded as empty in truncate mode
+func AnonymizeIP(ip, mode string) string {
+	switch mode {
+	case IPModeNone:
+		return ""
+	case IPModeTruncate:
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return ""
+		}
+		addr = addr.Unmap()
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		prefix, err := addr.Prefix(bits)
+		if err != nil {
+			return ""
+		}
+		return prefix.Addr().String()
+	default:
+		return ip
+	}
+}
@@ -0,0 +1,110 @@
+package analytics
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+)
+
+const (
+	// queueSize 是等待写入的访问记录的最大数量，队列满时新记录会被丢弃
+	// queueSize is the maximum number of hits waiting to be written; new hits are dropped when it is full
+	queueSize = 4096
+	// batchSize 是一次批量写入的最大记录数
+	// batchSize is the maximum number of hits written in one batch
+	batchSize = 200
+	// flushInterval 是未满批次的最长等待时间
+	// flushInterval is how long a partial batch waits before being written
+	flushInterval = time.Second
+)
+
+// Recorder 在后台批量写入短链接访问记录，使下载请求无需等待数据库
+// Recorder writes short link hits in background batches so downloads never wait on the database
+type Recorder struct {
+	links *repository.ShortLinkRepo
+	hits  chan models.ShortLinkHit
+	done  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewRecorder 创建将访问记录写入 links 的记录器并启动后台写入
+// NewRecorder creates a recorder writing hits to links and starts its background writer
+func NewRecorder(links *repository.ShortLinkRepo) *Recorder {
+	r := &Recorder{
+		links: links,
+		hits:  make(chan models.ShortLinkHit, queueSize),
+		done:  make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// Record 将访问记录加入队列，不会阻塞；队列已满或记录器已关闭时丢弃
+// Record queues a hit without blocking; it is dropped when the queue is full or the recorder is closed
+func (r *Recorder) Record(hit models.ShortLinkHit) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.closed {
+		return
+	}
+
+	if hit.CreatedAt.IsZero() {
+		hit.CreatedAt = time.Now().UTC()
+	}
+	select {
+	case r.hits <- hit:
+	default:
+		log.Printf("analytics: queue full, dropping hit for short link %d", hit.ShortLinkID)
+	}
+}
+
+// Close 停止接收新记录，并在写入剩余记录后返回
+// Close stops accepting hits and returns once the remaining ones are written
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.hits)
+	}
+	r.mu.Unlock()
+	<-r.done
+}
+
+func (r *Recorder) run() {
+	defer close(r.done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.ShortLinkHit, 0, batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := r.links.CreateHits(batch, batchSize); err != nil {
+			log.Printf("analytics: failed to write %d hits: %v", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case hit, ok := <-r.hits:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, hit)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+)
+
+// 被审计的操作 / Audited actions
+const (
+	ActionUpload           = "file.upload"
+	ActionDownload         = "file.download" // 仅 private 区域的文件 / Private files only
+	ActionDelete           = "file.delete"
+	ActionSignedURLCreate  = "signed_url.create"
+	ActionUploadTerminate  = "upload.terminate" // 放弃可续传上传 / Abandon a resumable upload
+	ActionShortLinkCreate  = "short_link.create"
+	ActionShortLinkUpdate  = "short_link.update"
+	ActionShortLinkDisable = "short_link.disable"
+	ActionShortLinkEnable  = "short_link.enable"
+	ActionAPIKeyCreate     = "api_key.create"
+	ActionAPIKeyDisable    = "api_key.disable"
+	ActionAPIKeyEnable     = "api_key.enable"
+)
+
+// AllActions 列出所有被审计的操作
+// AllActions lists every audited action
+var AllActions = []string{
+	ActionUpload, ActionDownload, ActionDelete, ActionSignedURLCreate, ActionUploadTerminate,
+	ActionShortLinkCreate, ActionShortLinkUpdate, ActionShortLinkDisable, ActionShortLinkEnable,
+	ActionAPIKeyCreate, ActionAPIKeyDisable, ActionAPIKeyEnable,
+}
+
+// 操作结果 / Outcomes
+const (
+	OutcomeSuccess = "success"
+	OutcomeDenied  = "denied"  // 401 或 403 / 401 or 403
+	OutcomeFailure = "failure" // 其他错误 / Any other error
+)
+
+// gin 上下文中标记待审计操作的键
+// gin context keys marking the operation to audit
+const (
+	actionContextKey = "audit_action"
+	targetContextKey = "audit_target"
+)
+
+// Logger 将审计记录写入数据库，并可选地追加到 JSON Lines 文件
+// Logger writes audit entries to the database and optionally appends them to a JSON Lines file
+type Logger struct {
+	logs *repository.AuditLogRepo
+	keys *repository.ApiKeyRepo
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewLogger 创建将审计记录写入 logs 的记录器，keys 用于识别被拒绝的密钥；path 非空时以追加方式打开 JSON Lines 镜像文件
+// NewLogger creates an audit logger writing entries to logs, with keys used to identify denied keys;
+// when path is not empty the JSON Lines mirror file is opened for appending
+func NewLogger(logs *repository.AuditLogRepo, keys *repository.ApiKeyRepo, path string) (*Logger, error) {
+	l := &Logger{logs: logs, keys: keys}
+	if path != "" {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		l.file = file
+	}
+	return l, nil
+}
+
+// Record 写入一条审计记录；与访问统计不同，它是同步的，写入失败只会记录日志而不会影响请求
+// Record writes one audit entry; unlike the hit analytics it is synchronous, and a failed write is logged without failing the request
+func (l *Logger) Record(entry models.AuditLog) {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	if err := l.logs.Create(&entry); err != nil {
+		log.Printf("audit: failed to store %s on %q: %v", entry.Action, entry.Target, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return
+	}
+	line, err := json.Marshal(newLine(entry))
+	if err != nil {
+		log.Printf("audit: failed to encode %s on %q: %v", entry.Action, entry.Target, err)
+		return
+	}
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		log.Printf("audit: failed to write %s on %q to the mirror file: %v", entry.Action, entry.Target, err)
+	}
+}
+
+// Close 关闭镜像文件
+// Close closes the mirror file
+func (l *Logger) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file != nil {
+		if err := l.file.Close(); err != nil {
+			log.Printf("audit: failed to close the mirror file: %v", err)
+		}
+		l.file = nil
+	}
+}
+
+// line 是镜像文件中的一行 / line is one line of the mirror file
+type line struct {
+	ID         uint      `json:"id,omitempty"`
+	Time       time.Time `json:"time"`
+	ActorKeyID *uint     `json:"actor_key_id"`
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	Outcome    string    `json:"outcome"`
+	Status     int       `json:"status"`
+}
+
+func newLine(entry models.AuditLog) line {
+	return line{
+		ID:         entry.ID,
+		Time:       entry.CreatedAt.UTC(),
+		ActorKeyID: entry.ActorKeyID,
+		Action:     entry.Action,
+		Target:     entry.Target,
+		ClientIP:   entry.ClientIP,
+		UserAgent:  entry.UserAgent,
+		Outcome:    entry.Outcome,
+		Status:     entry.Status,
+	}
+}
+
+// Action 返回将路由标记为 action 的中间件，目标默认为请求路径，处理器可用 SetTarget 细化
+// Action returns middleware marking the route as action; the target defaults to the request path and handlers can refine it with SetTarget
+func Action(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		Mark(c, action, c.Request.URL.Path)
+		c.Next()
+	}
+}
+
+// Mark 将当前请求标记为需要审计的操作，用于只有部分请求需要审计的路由（如下载 private 文件）
+// Mark flags the current request as an audited operation, for routes where only some requests are audited (such as private downloads)
+func Mark(c *gin.Context, action, target string) {
+	c.Set(actionContextKey, action)
+	c.Set(targetContextKey, target)
+}
+
+// SetTarget 设置被审计操作的目标
+// SetTarget sets the target of the audited operation
+func SetTarget(c *gin.Context, target string) {
+	c.Set(targetContextKey, target)
+}
+
+// FileTarget 返回文件的审计目标，如 private/report.pdf
+// FileTarget returns the audit target of a file, e.g. private/report.pdf
+func FileTarget(visibility, name string) string {
+	return visibility + "/" + name
+}
+
+// ShortLinkTarget 返回短链接的审计目标
+// ShortLinkTarget returns the audit target of a short link
+func ShortLinkTarget(code string) string {
+	return "short_link/" + code
+}
+
+// UploadTarget 返回可续传上传的审计目标
+// UploadTarget returns the audit target of a resumable upload
+func UploadTarget(id string) string {
+	return "upload/" + id
+}
+
+// APIKeyTarget 返回 API Key 的审计目标
+// APIKeyTarget returns the audit target of an API key
+func APIKeyTarget(id uint) string {
+	return "api_key/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// Handler 是审计中间件：请求被标记为需要审计时，在处理完成后按响应状态码记录结果
+// Handler is the audit middleware: once a request marked for auditing is handled, it records the outcome from the response status
+func (l *Logger) Handler(c *gin.Context) {
+	c.Next()
+
+	action := c.GetString(actionContextKey)
+	if action == "" {
+		return
+	}
+
+	status := c.Writer.Status()
+	outcome := OutcomeSuccess
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		outcome = OutcomeDenied
+	case status >= http.StatusBadRequest:
+		outcome = OutcomeFailure
+	}
+
+	l.Record(models.AuditLog{
+		ActorKeyID: l.actor(c),
+		Action:     action,
+		Target:     utility.Truncate(c.GetString(targetContextKey), 1024),
+		ClientIP:   c.ClientIP(),
+		UserAgent:  utility.Truncate(c.Request.UserAgent(), 512),
+		Outcome:    outcome,
+		Status:     status,
+	})
+}
+
+// actor 返回发起请求的 API Key；未通过验证（如缺少权限）时按请求中的 Token 查找，以便记录被拒绝的密钥
+// actor returns the API key behind the request; when it was not authenticated (e.g. it lacks the scope)
+// the Token in the request is looked up so denied keys are recorded too
+func (l *Logger) actor(c *gin.Context) *uint {
+	if apiKey, ok := utility.CurrentAPIKey(c); ok {
+		return &apiKey.ID
+	}
+	token := utility.RequestToken(c)
+	if token == "" {
+		return nil
+	}
+	apiKey, err := l.keys.FindByHash(models.HashAPIKey(token))
+	if err != nil {
+		return nil
+	}
+	return &apiKey.ID
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -9,10 +10,93 @@ import (
 // Config 存储所有应用程序的配置
 // Config stores all configuration for the application
 type Config struct {
-	DatabaseURL string `mapstructure:"DATABASE_URL"`
-	GoFiBaseDir string `mapstructure:"GOFI_BASE_DIR"`
-	GoFiPort    string `mapstructure:"GOFI_PORT"`
-	GinMode     string `mapstructure:"GIN_MODE"`
+	DatabaseURL    string `mapstructure:"DATABASE_URL"`
+	DatabaseDriver string `mapstructure:"DATABASE_DRIVER"` // postgres 或 sqlite，为空时根据 URL 判断 / postgres or sqlite, empty follows the URL
+	// 启动时是否自动执行迁移 / Whether migrations run automatically at startup
+	DatabaseAutoMigrate bool   `mapstructure:"DATABASE_AUTO_MIGRATE"`
+	GoFiBaseDir         string `mapstructure:"GOFI_BASE_DIR"`
+	GoFiPort            string `mapstructure:"GOFI_PORT"`
+	GinMode             string `mapstructure:"GIN_MODE"`
+
+	// 可信反向代理的 IP 或 CIDR；只有来自这些地址的请求才会按 X-Forwarded-For 和 X-Real-IP 确定客户端 IP，为空表示不信任任何代理
+	// IPs or CIDRs of trusted reverse proxies; only requests from them have their client IP taken from X-Forwarded-For and X-Real-IP, empty trusts no proxy
+	TrustedProxies []string `mapstructure:"TRUSTED_PROXIES"`
+
+	// HTTP 服务器超时，0 表示不限制；读写超时限制的是传输停顿的时长，而不是整个传输的时长
+	// HTTP server timeouts, 0 means unlimited; the read and write timeouts bound how long a transfer may stall, not how long it may take
+	HTTPReadHeaderTimeout time.Duration `mapstructure:"HTTP_READ_HEADER_TIMEOUT"`
+	HTTPReadTimeout       time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout      time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout       time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+
+	// 收到 SIGINT 或 SIGTERM 后等待进行中的请求完成的最长时间
+	// Longest time to wait for in-flight requests after SIGINT or SIGTERM
+	ShutdownGracePeriod time.Duration `mapstructure:"SHUTDOWN_GRACE_PERIOD"`
+
+	// 存储后端配置
+	// Storage backend configuration
+	StorageBackend    string        `mapstructure:"STORAGE_BACKEND"`
+	S3Endpoint        string        `mapstructure:"S3_ENDPOINT"`
+	S3Region          string        `mapstructure:"S3_REGION"`
+	S3Bucket          string        `mapstructure:"S3_BUCKET"`
+	S3Prefix          string        `mapstructure:"S3_PREFIX"`
+	S3AccessKeyID     string        `mapstructure:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey string        `mapstructure:"S3_SECRET_ACCESS_KEY"`
+	S3UseSSL          bool          `mapstructure:"S3_USE_SSL"`
+	S3DownloadMode    string        `mapstructure:"S3_DOWNLOAD_MODE"`
+	S3PresignExpiry   time.Duration `mapstructure:"S3_PRESIGN_EXPIRY"`
+
+	// 上传文件名冲突的默认处理方式
+	// Default handling of upload filename collisions
+	UploadConflictPolicy string `mapstructure:"UPLOAD_CONFLICT_POLICY"`
+	UploadRenameStyle    string `mapstructure:"UPLOAD_RENAME_STYLE"`
+
+	// 可续传上传（tus）配置
+	// Resumable upload (tus) configuration
+	TusUploadDir    string        `mapstructure:"TUS_UPLOAD_DIR"`
+	TusMaxSize      int64         `mapstructure:"TUS_MAX_SIZE"`
+	TusUploadExpiry time.Duration `mapstructure:"TUS_UPLOAD_EXPIRY"` // 未收到数据超过此时长的上传会被删除，0 表示永不删除 / Uploads idle for longer are removed, 0 means never
+
+	// 用于签名 Cookie 和 URL 的密钥；留空时启动时随机生成
+	// Secret used to sign cookies and URLs; generated randomly at startup when empty
+	SecretKey string `mapstructure:"SECRET_KEY"`
+
+	// 密码保护短链接解锁后 Cookie 的有效期
+	// How long the cookie stays valid after unlocking a password-protected short link
+	ShortLinkUnlockTTL time.Duration `mapstructure:"SHORT_LINK_UNLOCK_TTL"`
+
+	// 签名下载 URL 允许的最长有效期，0 表示不限制
+	// Longest lifetime allowed for signed download URLs, 0 means unlimited
+	SignedURLMaxExpiry time.Duration `mapstructure:"SIGNED_URL_MAX_EXPIRY"`
+
+	// 自动生成短代码的长度和字母表（hex、base62、base58 或自定义字符集）
+	// Length and alphabet of generated short codes (hex, base62, base58 or a literal character set)
+	ShortCodeLength   int    `mapstructure:"SHORT_CODE_LENGTH"`
+	ShortCodeAlphabet string `mapstructure:"SHORT_CODE_ALPHABET"`
+
+	// 短链接访问记录中客户端 IP 的记录方式 (full, truncate, none)
+	// How client IPs are stored in short link hit records (full, truncate, none)
+	ShortLinkHitIPMode string `mapstructure:"SHORT_LINK_HIT_IP_MODE"`
+
+	// 每个 API Key 和每个客户端 IP 的默认限流：每秒请求数、突发请求数和每秒字节数，0 表示不限制；
+	// API Key 可以单独覆盖自己的限制
+	// Default rate limits per API key and per client IP: requests per second, request burst and bytes per second, 0 means unlimited;
+	// API keys may override their own limits
+	RateLimitKeyRequests  float64 `mapstructure:"RATE_LIMIT_KEY_REQUESTS"`
+	RateLimitKeyBurst     int     `mapstructure:"RATE_LIMIT_KEY_BURST"`
+	RateLimitKeyBandwidth int64   `mapstructure:"RATE_LIMIT_KEY_BANDWIDTH"`
+	RateLimitIPRequests   float64 `mapstructure:"RATE_LIMIT_IP_REQUESTS"`
+	RateLimitIPBurst      int     `mapstructure:"RATE_LIMIT_IP_BURST"`
+	RateLimitIPBandwidth  int64   `mapstructure:"RATE_LIMIT_IP_BANDWIDTH"`
+
+	// 每个 API Key 默认可存储的字节数和全部文件的总上限，0 表示不限制
+	// Default bytes each API key may store and the cap for all files together, 0 means unlimited
+	StorageQuotaPerKey int64 `mapstructure:"STORAGE_QUOTA_PER_KEY"`
+	StorageQuotaTotal  int64 `mapstructure:"STORAGE_QUOTA_TOTAL"`
+
+	// 审计日志的 JSON Lines 镜像文件，为空表示只写入数据库
+	// JSON Lines file mirroring the audit log, empty means the database only
+	AuditLogFile string `mapstructure:"AUDIT_LOG_FILE"`
 }
 
 // LoadConfig 从配置文件和环境变量中加载配置，configPath 为空时默认当前目录下的 config.toml
@@ -40,7 +124,45 @@ func LoadConfig(configPath string) (*Config, error) {
 	// Set default values
 	v.SetDefault("GOFI_PORT", "8080")
 	v.SetDefault("GIN_MODE", "debug")
+	v.SetDefault("TRUSTED_PROXIES", []string{})
+	v.SetDefault("HTTP_READ_HEADER_TIMEOUT", "10s")
+	v.SetDefault("HTTP_READ_TIMEOUT", "60s")
+	v.SetDefault("HTTP_WRITE_TIMEOUT", "60s")
+	v.SetDefault("HTTP_IDLE_TIMEOUT", "120s")
+	v.SetDefault("SHUTDOWN_GRACE_PERIOD", "30s")
 	v.SetDefault("GOFI_BASE_DIR", "/app/data")
+	v.SetDefault("DATABASE_DRIVER", "")
+	v.SetDefault("DATABASE_AUTO_MIGRATE", true)
+	v.SetDefault("STORAGE_BACKEND", "localfs")
+	v.SetDefault("S3_ENDPOINT", "")
+	v.SetDefault("S3_REGION", "")
+	v.SetDefault("S3_BUCKET", "")
+	v.SetDefault("S3_PREFIX", "")
+	v.SetDefault("S3_ACCESS_KEY_ID", "")
+	v.SetDefault("S3_SECRET_ACCESS_KEY", "")
+	v.SetDefault("S3_USE_SSL", true)
+	v.SetDefault("S3_DOWNLOAD_MODE", "stream")
+	v.SetDefault("S3_PRESIGN_EXPIRY", "15m")
+	v.SetDefault("UPLOAD_CONFLICT_POLICY", "reject")
+	v.SetDefault("UPLOAD_RENAME_STYLE", "numeric")
+	v.SetDefault("TUS_UPLOAD_DIR", "")
+	v.SetDefault("TUS_MAX_SIZE", 0)
+	v.SetDefault("TUS_UPLOAD_EXPIRY", "24h")
+	v.SetDefault("SECRET_KEY", "")
+	v.SetDefault("SHORT_LINK_UNLOCK_TTL", "1h")
+	v.SetDefault("SIGNED_URL_MAX_EXPIRY", "168h")
+	v.SetDefault("SHORT_CODE_LENGTH", 10)
+	v.SetDefault("SHORT_CODE_ALPHABET", "hex")
+	v.SetDefault("SHORT_LINK_HIT_IP_MODE", "full")
+	v.SetDefault("RATE_LIMIT_KEY_REQUESTS", 0)
+	v.SetDefault("RATE_LIMIT_KEY_BURST", 0)
+	v.SetDefault("RATE_LIMIT_KEY_BANDWIDTH", 0)
+	v.SetDefault("RATE_LIMIT_IP_REQUESTS", 0)
+	v.SetDefault("RATE_LIMIT_IP_BURST", 0)
+	v.SetDefault("RATE_LIMIT_IP_BANDWIDTH", 0)
+	v.SetDefault("STORAGE_QUOTA_PER_KEY", 0)
+	v.SetDefault("STORAGE_QUOTA_TOTAL", 0)
+	v.SetDefault("AUDIT_LOG_FILE", "")
 
 	// 读取配置文件
 	// Read config file
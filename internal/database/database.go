@@ -1,34 +1,77 @@
 package database
 
 import (
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/ShinoharaHaruna/GoFi/internal/config"
-	"github.com/ShinoharaHaruna/GoFi/internal/models"
-	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-var DB *gorm.DB
+// Connect 打开数据库连接，不修改模式
+// Connect opens the database connection without touching the schema
+func Connect(cfg *config.Config) (*gorm.DB, error) {
+	dialect, driver, err := dialector(cfg)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite 以文本保存时间并按字符串比较，只有时区偏移相同时比较才正确，因此写入的时间统一使用 UTC；
+	// 作为查询条件的时间同样需要先转换为 UTC
+	// SQLite stores times as text and compares them as strings, which is only correct with the same offset,
+	// so every time written is in UTC; times used in query conditions must be converted to UTC as well
+	db, err := gorm.Open(dialect, &gorm.Config{NowFunc: func() time.Time { return time.Now().UTC() }})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	if driver == DriverSQLite && isInMemorySQLite(sqliteDSN(cfg.DatabaseURL)) {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
+		}
+		sqlDB.SetMaxOpenConns(1)
+	}
+
+	log.Printf("Database connection established (%s).", driver)
+	return db, nil
+}
 
-// InitDB 初始化数据库连接并自动迁移模式
-// InitDB initializes the database connection and auto-migrates the schema
-func InitDB(cfg *config.Config) error {
-	var err error
-	DB, err = gorm.Open(postgres.Open(cfg.DatabaseURL), &gorm.Config{})
+// InitDB 打开数据库连接并执行未执行的迁移；关闭 DATABASE_AUTO_MIGRATE 时只检查模式是否为最新版本
+// InitDB opens the database connection and applies pending migrations; with DATABASE_AUTO_MIGRATE off it only checks that the schema is current
+func InitDB(cfg *config.Config) (*gorm.DB, error) {
+	db, err := Connect(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return nil, err
 	}
 
-	log.Println("Database connection established.")
+	if !cfg.DatabaseAutoMigrate {
+		pending, err := PendingMigrations(db)
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			return nil, fmt.Errorf("database schema is %d migrations behind; run `gofi migrate up`", len(pending))
+		}
+		return db, nil
+	}
 
-	// 自动迁移模式
-	// Auto-migrate the schema
-	err = DB.AutoMigrate(&models.ShortLink{}, &models.ApiKey{})
+	applied, err := MigrateUp(db)
 	if err != nil {
-		log.Fatalf("Failed to migrate database: %v", err)
+		return nil, err
+	}
+	for _, m := range applied {
+		log.Printf("Applied migration %d (%s).", m.Version, m.Name)
 	}
+	log.Println("Database schema is up to date.")
+	return db, nil
+}
 
-	log.Println("Database schema migrated.")
-	return nil
+// Close 关闭数据库连接池
+// Close closes the database connection pool
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
 }
@@ -0,0 +1,85 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/glebarez/sqlite"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// 支持的数据库驱动 / Supported database drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// sqlitePragmas 是每个 SQLite 连接的设置：启用外键以支持级联删除，等待而不是立即报告锁冲突，
+// 使用 WAL 以便读写并发，并让 LIKE 与 PostgreSQL 一样区分大小写
+// sqlitePragmas configure every SQLite connection: foreign keys enforce cascading deletes, lock conflicts wait instead of failing,
+// WAL lets readers and writers run concurrently, and LIKE is case-sensitive as in PostgreSQL
+var sqlitePragmas = []string{
+	"_pragma=foreign_keys(1)",
+	"_pragma=busy_timeout(5000)",
+	"_pragma=journal_mode(WAL)",
+	"_pragma=case_sensitive_like(1)",
+	// 事务开始时即获取写锁，避免读事务升级为写事务时因锁冲突直接失败
+	// Take the write lock when a transaction begins, so upgrading a read transaction never fails on a lock conflict
+	"_txlock=immediate",
+}
+
+// ResolveDriver 返回要使用的数据库驱动：DATABASE_DRIVER 优先，否则根据 DATABASE_URL 的协议判断
+// ResolveDriver returns the database driver to use: DATABASE_DRIVER wins, otherwise it follows the scheme of DATABASE_URL
+func ResolveDriver(cfg *config.Config) (string, error) {
+	switch driver := strings.ToLower(cfg.DatabaseDriver); driver {
+	case DriverPostgres, DriverSQLite:
+		return driver, nil
+	case "":
+	default:
+		return "", fmt.Errorf("unsupported DATABASE_DRIVER %q: must be postgres or sqlite", cfg.DatabaseDriver)
+	}
+
+	url := strings.ToLower(cfg.DatabaseURL)
+	if strings.HasPrefix(url, "sqlite:") || strings.HasPrefix(url, "file:") {
+		return DriverSQLite, nil
+	}
+	return DriverPostgres, nil
+}
+
+// dialector 根据配置创建 GORM 方言
+// dialector creates the GORM dialector for the configuration
+func dialector(cfg *config.Config) (gorm.Dialector, string, error) {
+	driver, err := ResolveDriver(cfg)
+	if err != nil {
+		return nil, "", err
+	}
+	if driver == DriverPostgres {
+		return postgres.Open(cfg.DatabaseURL), driver, nil
+	}
+	return sqlite.Open(sqliteDSN(cfg.DatabaseURL)), driver, nil
+}
+
+// sqliteDSN 将 DATABASE_URL 转换为 SQLite 的 DSN：去掉 sqlite:// 或 sqlite: 前缀并附加连接设置。
+// 例如 sqlite:///var/lib/gofi/gofi.db 为绝对路径，sqlite://gofi.db 为相对路径，sqlite::memory: 为内存数据库。
+// sqliteDSN turns DATABASE_URL into an SQLite DSN: it strips the sqlite:// or sqlite: prefix and appends the connection settings.
+// For example sqlite:///var/lib/gofi/gofi.db is an absolute path, sqlite://gofi.db a relative one and sqlite::memory: an in-memory database.
+func sqliteDSN(url string) string {
+	dsn := url
+	if len(dsn) >= len("sqlite:") && strings.EqualFold(dsn[:len("sqlite:")], "sqlite:") {
+		dsn = strings.TrimPrefix(dsn[len("sqlite:"):], "//")
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+	return dsn + separator + strings.Join(sqlitePragmas, "&")
+}
+
+// isInMemorySQLite 判断 DSN 是否指向内存数据库；每个连接都会得到各自的内存数据库，因此只能使用一个连接
+// isInMemorySQLite reports whether the DSN points at an in-memory database; every connection would get its own, so only one may be used
+func isInMemorySQLite(dsn string) bool {
+	return strings.HasPrefix(dsn, ":memory:") || strings.Contains(dsn, "mode=memory")
+}
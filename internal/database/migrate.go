@@ -0,0 +1,191 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID 是迁移时使用的 PostgreSQL advisory lock 键（"gofi"）
+// migrationLockID is the PostgreSQL advisory lock key taken while migrating ("gofi")
+const migrationLockID = 0x676f6669
+
+// ErrIrreversible 表示迁移无法回滚
+// ErrIrreversible reports that a migration cannot be rolled back
+var ErrIrreversible = errors.New("migration cannot be rolled back")
+
+// Migration 是一次有版本号的模式变更；Down 为空表示无法回滚
+// Migration is one versioned schema change; a nil Down means it cannot be rolled back
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationState 表示迁移及其执行时间，未执行时 AppliedAt 为空
+// MigrationState is a migration and when it was applied; AppliedAt is nil while it is pending
+type MigrationState struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// SchemaMigration 对应于数据库中的 schema_migrations 表，记录已执行的迁移
+// SchemaMigration corresponds to the schema_migrations table in the database and records the applied migrations
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"type:varchar(255);not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// TableName 返回迁移记录表名 / TableName returns the table of the migration records
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// MigrateUp 按版本顺序执行所有未执行的迁移，每个迁移在单独的事务中执行，返回本次执行的迁移
+// MigrateUp applies every pending migration in version order, each in its own transaction, and returns the ones it applied
+func MigrateUp(db *gorm.DB) ([]Migration, error) {
+	var applied []Migration
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		if err := conn.AutoMigrate(&SchemaMigration{}); err != nil {
+			return err
+		}
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for _, m := range migrations {
+			if _, ok := done[m.Version]; ok {
+				continue
+			}
+			ran := false
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				// 在事务中再次确认，SQLite 依靠事务开始时获取的写锁防止并发执行
+				// Check again inside the transaction; SQLite relies on the write lock taken when it begins to prevent concurrent runs
+				var count int64
+				if err := tx.Model(&SchemaMigration{}).Where("version = ?", m.Version).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					return nil
+				}
+				if err := m.Up(tx); err != nil {
+					return err
+				}
+				ran = true
+				return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			if ran {
+				applied = append(applied, m)
+			}
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// MigrateDown 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+// MigrateDown rolls back the last steps applied migrations in reverse version order and returns the ones it rolled back
+func MigrateDown(db *gorm.DB, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := withMigrationLock(db, func(conn *gorm.DB) error {
+		done, err := appliedMigrations(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if _, ok := done[m.Version]; !ok {
+				continue
+			}
+			if m.Down == nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, ErrIrreversible)
+			}
+			err := conn.Transaction(func(tx *gorm.DB) error {
+				if err := m.Down(tx); err != nil {
+					return err
+				}
+				return tx.Where("version = ?", m.Version).Delete(&SchemaMigration{}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// MigrationStatus 返回所有已知迁移的执行状态
+// MigrationStatus returns the state of every known migration
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	done, err := appliedMigrations(db)
+	if err != nil {
+		return nil, err
+	}
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		states[i].Migration = m
+		if record, ok := done[m.Version]; ok {
+			states[i].AppliedAt = &record.AppliedAt
+		}
+	}
+	return states, nil
+}
+
+// PendingMigrations 返回尚未执行的迁移
+// PendingMigrations returns the migrations that have not been applied yet
+func PendingMigrations(db *gorm.DB) ([]Migration, error) {
+	states, err := MigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, state := range states {
+		if state.AppliedAt == nil {
+			pending = append(pending, state.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// appliedMigrations 按版本返回已执行的迁移；迁移记录表不存在时视为尚未执行任何迁移
+// appliedMigrations returns the applied migrations by version; without the migration records table nothing has been applied yet
+func appliedMigrations(db *gorm.DB) (map[uint]SchemaMigration, error) {
+	if !db.Migrator().HasTable(&SchemaMigration{}) {
+		return map[uint]SchemaMigration{}, nil
+	}
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[uint]SchemaMigration, len(records))
+	for _, record := range records {
+		done[record.Version] = record
+	}
+	return done, nil
+}
+
+// withMigrationLock 在持有迁移锁时执行 fn，防止多个副本同时迁移。
+// PostgreSQL 使用会话级 advisory lock，因此 fn 必须使用传入的同一连接；SQLite 的事务在开始时即获取写锁，已足以串行化。
+// withMigrationLock runs fn while holding the migration lock, so two replicas never migrate at the same time.
+// PostgreSQL uses a session-level advisory lock, so fn must use the connection it is given; SQLite transactions take the write lock when they begin, which already serializes them.
+func withMigrationLock(db *gorm.DB, fn func(conn *gorm.DB) error) error {
+	if db.Dialector.Name() != DriverPostgres {
+		return fn(db)
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", migrationLockID).Error; err != nil {
+			return fmt.Errorf("failed to take the migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", migrationLockID)
+		return fn(conn)
+	})
+}
@@ -0,0 +1,295 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// migrations 按版本顺序列出所有模式迁移。已发布的迁移不能再修改：模式变更需要追加新的迁移，
+// 并且迁移中使用自己的结构体或 SQL，而不是 models 中会随代码变化的模型。
+// migrations lists every schema migration in version order. Released migrations must never change: a schema change appends
+// a new migration, and migrations use their own structs or SQL rather than the models, which change with the code.
+var migrations = []Migration{
+	{
+		// 将明文保存的旧 API Key 转换为哈希；哈希无法还原，因此不能回滚
+		// Convert old API keys stored in plain text into hashes; hashes cannot be reversed, so this cannot be rolled back
+		Version: 1,
+		Name:    "hash_api_keys",
+		Up:      migrateAPIKeyHashes,
+	},
+	{
+		// 将旧 API Key 的单一类型转换为权限范围
+		// Convert the single type of old API keys into scopes
+		Version: 2,
+		Name:    "api_key_scopes",
+		Up:      migrateAPIKeyScopes,
+	},
+	{
+		// 引入版本化迁移时的完整模式；对于之前由 AutoMigrate 创建的数据库，只补齐缺少的表、列和索引
+		// The full schema as of the introduction of versioned migrations; databases created by AutoMigrate
+		// before that only get their missing tables, columns and indexes
+		Version: 3,
+		Name:    "baseline_schema",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&baselineFile{}, &baselineShortLink{}, &baselineShortLinkHit{}, &baselineAPIKey{}, &baselineAuditLog{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&baselineAuditLog{}, &baselineShortLinkHit{}, &baselineShortLink{}, &baselineAPIKey{}, &baselineFile{})
+		},
+	},
+	{
+		// 标记仍未关联文件的旧短链接，启动时只关联这些短链接一次；已删除文件留下的短链接不会被关联到新上传的同名文件
+		// Mark the legacy short links not yet linked to a file, so startup links only these, once; links left behind by
+		// deleted files are never linked to new uploads with the same name
+		Version: 4,
+		Name:    "mark_legacy_short_links",
+		Up:      markLegacyShortLinks,
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&legacyShortLink{}, "legacy_unlinked")
+		},
+	},
+}
+
+// migrateAPIKeyHashes 为仍保存明文 key 列的 api_keys 表计算前缀和哈希，然后删除明文列
+// migrateAPIKeyHashes computes the prefix and hash for an api_keys table that still has the plain-text key column, then drops that column
+func migrateAPIKeyHashes(db *gorm.DB) error {
+	if !db.Migrator().HasTable("api_keys") {
+		return nil
+	}
+	if hasKey, err := hasColumn(db, "api_keys", "key"); err != nil || !hasKey {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, column := range []string{"prefix varchar(16)", "key_hash varchar(64)"} {
+			name, _, _ := strings.Cut(column, " ")
+			exists, err := hasColumn(tx, "api_keys", name)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				if err := tx.Exec("ALTER TABLE api_keys ADD COLUMN " + column + " NOT NULL DEFAULT ''").Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		var rows []struct {
+			ID  uint
+			Key string
+		}
+		if err := tx.Table("api_keys").Select("id", "key").Find(&rows).Error; err != nil {
+			return err
+		}
+		for _, row := range rows {
+			err := tx.Table("api_keys").Where("id = ?", row.ID).Updates(map[string]any{
+				"prefix":   apiKeyPrefixV1(row.Key),
+				"key_hash": hashAPIKeyV1(row.Key),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Migrator().DropColumn(&legacyAPIKey{}, "key"); err != nil {
+			return err
+		}
+		log.Printf("Hashed %d API keys and removed the plain-text key column.", len(rows))
+		return nil
+	})
+}
+
+// apiKeyPrefixV1 是迁移 1 时 models.APIKeyPrefix 的副本，不随其变化
+// apiKeyPrefixV1 is a copy of models.APIKeyPrefix as of migration 1 and does not follow it
+func apiKeyPrefixV1(key string) string {
+	if rest, ok := strings.CutPrefix(key, "gofi_"); ok {
+		if prefix, _, ok := strings.Cut(rest, "_"); ok {
+			return prefix
+		}
+	}
+	return key[:min(8, len(key)/2)]
+}
+
+// hashAPIKeyV1 是迁移 1 时 models.HashAPIKey 的副本，不随其变化
+// hashAPIKeyV1 is a copy of models.HashAPIKey as of migration 1 and does not follow it
+func hashAPIKeyV1(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// migrateAPIKeyScopes 将旧的单一 type 列转换为 scopes 列，原有密钥保持相同的权限
+// migrateAPIKeyScopes converts the old single type column into the scopes column, so existing keys keep the same permissions
+func migrateAPIKeyScopes(db *gorm.DB) error {
+	if !db.Migrator().HasTable("api_keys") {
+		return nil
+	}
+	if hasType, err := hasColumn(db, "api_keys", "type"); err != nil || !hasType {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		hasScopes, err := hasColumn(tx, "api_keys", "scopes")
+		if err != nil {
+			return err
+		}
+		if !hasScopes {
+			if err := tx.Exec("ALTER TABLE api_keys ADD COLUMN scopes varchar(255) NOT NULL DEFAULT ''").Error; err != nil {
+				return err
+			}
+		}
+
+		result := tx.Exec("UPDATE api_keys SET scopes = type WHERE scopes = ''")
+		if result.Error != nil {
+			return result.Error
+		}
+		if err := tx.Migrator().DropColumn(&legacyAPIKey{}, "type"); err != nil {
+			return err
+		}
+		log.Printf("Converted the type of %d API keys into scopes.", result.RowsAffected)
+		return nil
+	})
+}
+
+// markLegacyShortLinks 添加 legacy_unlinked 列，并标记所有尚未关联文件的短链接
+// markLegacyShortLinks adds the legacy_unlinked column and marks every short link not yet linked to a file
+func markLegacyShortLinks(tx *gorm.DB) error {
+	exists, err := hasColumn(tx, "short_links", "legacy_unlinked")
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err := tx.Exec("ALTER TABLE short_links ADD COLUMN legacy_unlinked boolean NOT NULL DEFAULT false").Error; err != nil {
+			return err
+		}
+	}
+	result := tx.Exec("UPDATE short_links SET legacy_unlinked = ? WHERE file_id IS NULL", true)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Marked %d legacy short links to be linked to their files.", result.RowsAffected)
+	}
+	return nil
+}
+
+// hasColumn 按列名精确判断表中是否存在某列
+// hasColumn reports whether the table has a column with exactly this name
+func hasColumn(db *gorm.DB, table, name string) (bool, error) {
+	columns, err := db.Migrator().ColumnTypes(table)
+	if err != nil {
+		return false, err
+	}
+	for _, column := range columns {
+		if column.Name() == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 以下结构体是版本 3 时的模式快照，不随 models 变化
+// The structs below snapshot the schema as of version 3 and do not follow the models
+
+type baselineFile struct {
+	ID           uint      `gorm:"primaryKey"`
+	Name         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_files_visibility_name,priority:2"`
+	Visibility   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_files_visibility_name,priority:1"`
+	OriginalName string    `gorm:"type:varchar(255);not null"`
+	Size         int64     `gorm:"not null"`
+	SHA256       string    `gorm:"column:sha256;type:varchar(64);not null"`
+	MimeType     string    `gorm:"type:varchar(255);not null"`
+	ApiKeyID     *uint     `gorm:"index"`
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+func (baselineFile) TableName() string { return "files" }
+
+type baselineShortLink struct {
+	ID               uint          `gorm:"primaryKey"`
+	ShortCode        string        `gorm:"type:varchar(64);uniqueIndex;not null"`
+	FileID           *uint         `gorm:"index"`
+	File             *baselineFile `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	OriginalFilename string        `gorm:"type:varchar(255);not null"`
+	IsPrivate        bool          `gorm:"not null;default:true"`
+	Visibility       string        `gorm:"type:varchar(20);not null;default:''"`
+	IsEnabled        bool          `gorm:"not null;default:true"`
+	ExpiresAt        *time.Time
+	MaxDownloads     *int64
+	DownloadCount    int64     `gorm:"not null;default:0"`
+	PasswordHash     string    `gorm:"type:varchar(100)"`
+	CreatedByKeyID   *uint     `gorm:"index"`
+	CreatedAt        time.Time `gorm:"autoCreateTime"`
+}
+
+func (baselineShortLink) TableName() string { return "short_links" }
+
+type baselineShortLinkHit struct {
+	ID          uint               `gorm:"primaryKey"`
+	ShortLinkID uint               `gorm:"not null;index:idx_short_link_hits_link_time,priority:1"`
+	ShortLink   *baselineShortLink `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ClientIP    string             `gorm:"type:varchar(45)"`
+	UserAgent   string             `gorm:"type:varchar(512)"`
+	Referrer    string             `gorm:"type:varchar(1024)"`
+	Status      int                `gorm:"not null"`
+	BytesServed int64              `gorm:"not null;default:0"`
+	CreatedAt   time.Time          `gorm:"autoCreateTime;index:idx_short_link_hits_link_time,priority:2"`
+}
+
+func (baselineShortLinkHit) TableName() string { return "short_link_hits" }
+
+type baselineAPIKey struct {
+	gorm.Model
+	Prefix             string     `gorm:"type:varchar(16);index;not null"`
+	KeyHash            string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	Scopes             string     `gorm:"type:varchar(255);not null"`
+	Label              string     `gorm:"type:varchar(100);not null;default:''"`
+	IsEnabled          bool       `gorm:"default:true"`
+	ExpiresAt          *time.Time `gorm:"index"`
+	LastUsedAt         *time.Time
+	LastUsedIP         string `gorm:"type:varchar(45);not null;default:''"`
+	Visibility         string `gorm:"type:varchar(20);not null;default:''"`
+	NamePattern        string `gorm:"type:varchar(255);not null;default:''"`
+	RateLimitRequests  *float64
+	RateLimitBurst     *int
+	RateLimitBandwidth *int64
+	StorageQuota       *int64
+}
+
+func (baselineAPIKey) TableName() string { return "api_keys" }
+
+type baselineAuditLog struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index"`
+	ActorKeyID *uint     `gorm:"index"`
+	Action     string    `gorm:"type:varchar(50);not null;index"`
+	Target     string    `gorm:"type:varchar(1024);not null"`
+	ClientIP   string    `gorm:"type:varchar(45)"`
+	UserAgent  string    `gorm:"type:varchar(512)"`
+	Outcome    string    `gorm:"type:varchar(20);not null;index"`
+	Status     int       `gorm:"not null"`
+}
+
+func (baselineAuditLog) TableName() string { return "audit_logs" }
+
+// legacyAPIKey 描述 api_keys 表在迁移 1、2 之前已删除的列；SQLite 删除列时需要模型来重建表
+// legacyAPIKey describes the api_keys columns removed by migrations 1 and 2; SQLite needs a model to rebuild the table when dropping a column
+type legacyAPIKey struct {
+	Key  string
+	Type string
+}
+
+func (legacyAPIKey) TableName() string { return "api_keys" }
+
+// legacyShortLink 描述迁移 4 添加的 short_links 列，供回滚时删除
+// legacyShortLink describes the short_links column added by migration 4, for dropping it on rollback
+type legacyShortLink struct {
+	LegacyUnlinked bool
+}
+
+func (legacyShortLink) TableName() string { return "short_links" }
@@ -3,10 +3,14 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/database"
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
 	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
 	"github.com/ShinoharaHaruna/GoFi/internal/utility"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,20 +18,65 @@ import (
 
 // CreateAPIKeyRequest 定义创建 API Key 的请求体 / CreateAPIKeyRequest defines the request body for creating an API key
 type CreateAPIKeyRequest struct {
-	Type string `json:"type" binding:"required"`
+	Scopes    []string   `json:"scopes,omitempty"`     // 权限范围 / Scopes
+	Type      string     `json:"type,omitempty"`       // 已弃用：单一权限范围 / Deprecated: a single scope
+	Label     string     `json:"label,omitempty"`      // 用途或持有人 / Purpose or owner
+	ExpiresAt *time.Time `json:"expires_at,omitempty"` // RFC 3339 过期时间 / RFC 3339 expiry time
+	ExpiresIn string     `json:"expires_in,omitempty"` // 相对过期时间，如 "720h" / Relative expiry, e.g. "720h"
+
+	// 路径限制，为空表示不限制 / Path restriction, empty means unrestricted
+	Visibility  string `json:"visibility,omitempty"`   // public 或 private / public or private
+	NamePattern string `json:"name_pattern,omitempty"` // 文件名 glob，如 "team-a-*" / Filename glob, e.g. "team-a-*"
+
+	// 限流覆盖，为空时使用配置中的默认值，0 表示不限制 / Rate limit overrides, null uses the configured default, 0 means unlimited
+	RateLimitRequests  *float64 `json:"rate_limit_requests,omitempty"`  // 每秒请求数 / Requests per second
+	RateLimitBurst     *int     `json:"rate_limit_burst,omitempty"`     // 突发请求数 / Request burst
+	RateLimitBandwidth *int64   `json:"rate_limit_bandwidth,omitempty"` // 每秒字节数 / Bytes per second
+
+	// 存储配额（字节），为空时使用配置中的默认值，0 表示不限制 / Storage quota in bytes, null uses the configured default, 0 means unlimited
+	StorageQuota *int64 `json:"storage_quota,omitempty"`
 }
 
-// ApiKeyResponse 表示 API Key 的响应结构 / ApiKeyResponse represents the response structure for an API key
+// ApiKeyResponse 表示 API Key 的响应结构，完整密钥只在创建时返回 / ApiKeyResponse represents the response structure for an API key; the full key is only returned on creation
 type ApiKeyResponse struct {
-	Key       string            `json:"key"`
-	Type      models.ApiKeyType `json:"type"`
-	IsEnabled bool              `json:"is_enabled"`
+	ID          uint           `json:"id"`
+	Prefix      string         `json:"prefix"`
+	Key         string         `json:"key,omitempty"`
+	Scopes      []models.Scope `json:"scopes"`
+	Label       string         `json:"label"`
+	IsEnabled   bool           `json:"is_enabled"`
+	IsExpired   bool           `json:"is_expired"`
+	ExpiresAt   *time.Time     `json:"expires_at"`
+	LastUsedAt  *time.Time     `json:"last_used_at"`
+	LastUsedIP  string         `json:"last_used_ip"`
+	Visibility  string         `json:"visibility"`   // 为空表示不限制区域 / Empty means any area
+	NamePattern string         `json:"name_pattern"` // 为空表示不限制文件名 / Empty means any filename
+	// 为空表示使用默认限流 / Null means the default rate limit
+	RateLimitRequests  *float64  `json:"rate_limit_requests"`
+	RateLimitBurst     *int      `json:"rate_limit_burst"`
+	RateLimitBandwidth *int64    `json:"rate_limit_bandwidth"`
+	StorageQuota       *int64    `json:"storage_quota"` // 为空表示使用默认配额 / Null means the default quota
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// ApiKeyListResponse 表示分页的 API Key 列表 / ApiKeyListResponse represents a paginated API key listing
+type ApiKeyListResponse struct {
+	Page
+	Items []ApiKeyResponse `json:"items"`
+}
+
+// apiKeySortColumns 将排序字段映射到数据库列 / apiKeySortColumns maps sort fields to database columns
+var apiKeySortColumns = map[string]string{
+	"created_at":   "created_at",
+	"last_used_at": "last_used_at",
+	"expires_at":   "expires_at",
+	"label":        "label",
 }
 
 // CreateAPIKey godoc
 //
 //	@Summary		Create API key
-//	@Description	Create a new API key. Requires an `api` type key.
+//	@Description	Create a new API key. The full key is only returned in this response; store it safely. Granting the `admin` scope, rate limits or a storage quota requires an `admin` key. Requires an `api` scope key.
 //	@Tags			API Keys
 //	@Accept			json
 //	@Produce		json
@@ -36,10 +85,11 @@ type ApiKeyResponse struct {
 //	@Success		201	{object}	ApiKeyResponse
 //	@Failure		400	{object}	object{error=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
 //	@Router			/api-keys [post]
-func CreateAPIKey(c *gin.Context) {
-	if !utility.IsTokenValid(c, models.ApiKeyTypeAPI) {
+func (s *Server) CreateAPIKey(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAPI) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -50,58 +100,266 @@ func CreateAPIKey(c *gin.Context) {
 		return
 	}
 
-	keyType, ok := parseAPIKeyType(req.Type)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key type"})
+	// 兼容只提供 type 的旧请求
+	// Stay compatible with old requests that only send type
+	names := req.Scopes
+	if req.Type != "" {
+		names = append(names, req.Type)
+	}
+	if len(names) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one scope is required"})
+		return
+	}
+	scopes := make([]models.Scope, 0, len(names))
+	for _, name := range names {
+		scope, ok := models.ParseScope(name)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + name})
+			return
+		}
+		scopes = append(scopes, scope)
+	}
+
+	// 只有 admin 密钥才能签发 admin 密钥，避免 api 密钥提升自身权限
+	// Only admin keys may issue admin keys, so an api key cannot escalate its own privileges
+	if slices.Contains(scopes, models.ScopeAdmin) {
+		if caller, _ := utility.CurrentAPIKey(c); !caller.HasScope(models.ScopeAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admin keys can grant the admin scope"})
+			return
+		}
+	}
+
+	label := strings.TrimSpace(req.Label)
+	if len(label) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "label must be at most 100 bytes"})
+		return
+	}
+	expiresAt, err := resolveExpiry(req.ExpiresAt, req.ExpiresIn, time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 校验路径限制；受限制的调用方只能签发带有相同限制的密钥
+	// Validate the path restriction; restricted callers can only issue keys with the same restriction
+	if req.Visibility != "" {
+		if _, ok := storage.ParseVisibility(req.Visibility); !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "visibility must be public or private"})
+			return
+		}
+	}
+	if err := validateNamePattern(req.NamePattern); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if caller, _ := utility.CurrentAPIKey(c); caller.IsRestricted() {
+		if req.Visibility == "" && req.NamePattern == "" {
+			req.Visibility, req.NamePattern = caller.Visibility, caller.NamePattern
+		} else if req.Visibility != caller.Visibility || req.NamePattern != caller.NamePattern {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Restricted keys can only create keys with the same path restriction"})
+			return
+		}
+	}
+
+	// 只有 admin 密钥才能调整限流，避免密钥签发不受限流约束的新密钥
+	// Only admin keys may adjust rate limits, so keys cannot issue new keys that escape rate limiting
+	if req.RateLimitRequests != nil || req.RateLimitBurst != nil || req.RateLimitBandwidth != nil {
+		if caller, _ := utility.CurrentAPIKey(c); !caller.HasScope(models.ScopeAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admin keys can set rate limits"})
+			return
+		}
+		if (req.RateLimitRequests != nil && *req.RateLimitRequests < 0) ||
+			(req.RateLimitBurst != nil && *req.RateLimitBurst < 0) ||
+			(req.RateLimitBandwidth != nil && *req.RateLimitBandwidth < 0) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Rate limits must not be negative"})
+			return
+		}
+	}
 
-	keyValue, err := utility.GenerateUUIDv4()
+	// 存储配额同样只能由 admin 密钥设置
+	// Storage quotas can likewise only be set by admin keys
+	if req.StorageQuota != nil {
+		if caller, _ := utility.CurrentAPIKey(c); !caller.HasScope(models.ScopeAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only admin keys can set storage quotas"})
+			return
+		}
+		if *req.StorageQuota < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "storage_quota must not be negative"})
+			return
+		}
+	}
+
+	keyValue, prefix, err := utility.GenerateAPIKey()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate key"})
 		return
 	}
 
 	apiKey := models.ApiKey{
-		Key:       keyValue,
-		Type:      keyType,
-		IsEnabled: true,
+		Prefix:      prefix,
+		KeyHash:     models.HashAPIKey(keyValue),
+		Label:       label,
+		IsEnabled:   true,
+		ExpiresAt:   expiresAt,
+		Visibility:  req.Visibility,
+		NamePattern: req.NamePattern,
+
+		RateLimitRequests:  req.RateLimitRequests,
+		RateLimitBurst:     req.RateLimitBurst,
+		RateLimitBandwidth: req.RateLimitBandwidth,
+		StorageQuota:       req.StorageQuota,
 	}
+	apiKey.SetScopes(scopes)
 
-	if err := database.DB.Create(&apiKey).Error; err != nil {
+	if err := s.APIKeys.Create(&apiKey); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
 		return
 	}
+	audit.SetTarget(c, audit.APIKeyTarget(apiKey.ID))
+
+	response := newAPIKeyResponse(apiKey, time.Now())
+	response.Key = keyValue
+	c.JSON(http.StatusCreated, response)
+}
+
+// ListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	Returns a paginated list of API keys with their label, expiry and last use. Keys themselves are never returned. Requires an `api` scope key; keys other than admin keys only see the keys they may manage.
+//	@Tags			API Keys
+//	@Produce		json
+//	@Param			label			query	string	false	"Only keys whose label matches ('*' and '?' act as wildcards)"
+//	@Param			scope			query	string	false	"Only keys explicitly granted this scope"
+//	@Param			enabled			query	boolean	false	"Only enabled or disabled keys"
+//	@Param			expired			query	boolean	false	"Only expired or unexpired keys"
+//	@Param			unused_since	query	string	false	"Only keys not used since this RFC 3339 time, including keys never used"
+//	@Param			sort			query	string	false	"Sort field (default created_at)"	Enums(created_at, last_used_at, expires_at, label)
+//	@Param			order			query	string	false	"Sort order (default desc)"			Enums(asc, desc)
+//	@Param			page			query	integer	false	"Page number, starting at 1"
+//	@Param			page_size		query	integer	false	"Items per page (default 50, max 500)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	ApiKeyListResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api-keys [get]
+func (s *Server) ListAPIKeys(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAPI) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析分页、排序和过滤参数
+	// 2. Parse pagination, sorting and filter parameters
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orderBy, err := parseSort(c, apiKeySortColumns, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	query := restrictAPIKeyQuery(c, s.APIKeys.Query())
+	if label := c.Query("label"); label != "" {
+		query = query.Where(`label LIKE ? ESCAPE '\'`, globToLike(label))
+	}
+
+	if raw := c.Query("scope"); raw != "" {
+		scope, ok := models.ParseScope(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope: " + raw})
+			return
+		}
+		// scopes 以逗号分隔，两端补上逗号后按整项匹配
+		// scopes is comma-separated; padding both ends with commas matches whole entries
+		query = query.Where("',' || scopes || ',' LIKE ?", "%,"+string(scope)+",%")
+	}
+
+	enabled, err := parseBoolQuery(c, "enabled")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if enabled != nil {
+		query = query.Where("is_enabled = ?", *enabled)
+	}
+
+	expired, err := parseBoolQuery(c, "expired")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if expired != nil {
+		if *expired {
+			query = query.Where("expires_at <= ?", now)
+		} else {
+			query = query.Where("expires_at IS NULL OR expires_at > ?", now)
+		}
+	}
+
+	unusedSince, err := parseTimeQuery(c, "unused_since")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if unusedSince != nil {
+		query = query.Where("last_used_at IS NULL OR last_used_at < ?", *unusedSince)
+	}
+
+	// 3. 查询当前页
+	// 3. Query the current page
+	query, err = paginate(query, &page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count API keys"})
+		return
+	}
+	var apiKeys []models.ApiKey
+	if err := query.Order(orderBy).Order("id").Find(&apiKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
 
-	c.JSON(http.StatusCreated, ApiKeyResponse{
-		Key:       apiKey.Key,
-		Type:      apiKey.Type,
-		IsEnabled: apiKey.IsEnabled,
-	})
+	response := ApiKeyListResponse{Page: page, Items: make([]ApiKeyResponse, len(apiKeys))}
+	for i, apiKey := range apiKeys {
+		response.Items[i] = newAPIKeyResponse(apiKey, now)
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DisableAPIKey godoc
 //
 //	@Summary		Disable API key
-//	@Description	Soft-disable an API key by setting its is_enabled flag to false.
+//	@Description	Soft-disable an API key by setting its is_enabled flag to false. Only admin keys can disable admin keys, and restricted keys can only disable keys within their own path restriction.
 //	@Tags			API Keys
-//	@Param			key	path	string	true	"API key value"
+//	@Param			id	path	string	true	"API key ID or prefix"
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	object{message=string}
 //	@Failure		400	{object}	object{error=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
-//	@Router			/api-keys/{key} [delete]
-func DisableAPIKey(c *gin.Context) {
-	if !utility.IsTokenValid(c, models.ApiKeyTypeAPI) {
+//	@Router			/api-keys/{id} [delete]
+func (s *Server) DisableAPIKey(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAPI) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	apiKey, err := findAPIKeyByKeyParam(c)
+	apiKey, err := s.findAPIKeyByParam(c)
 	if err != nil {
-		// findAPIKeyByKeyParam 已返回相应的响应 / findAPIKeyByKeyParam already responded
+		// findAPIKeyByParam 已返回相应的响应 / findAPIKeyByParam already responded
+		return
+	}
+	audit.SetTarget(c, audit.APIKeyTarget(apiKey.ID))
+	if !authorizeAPIKeyManagement(c, apiKey) {
 		return
 	}
 
@@ -110,7 +368,7 @@ func DisableAPIKey(c *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Model(&apiKey).Update("is_enabled", false).Error; err != nil {
+	if err := s.APIKeys.SetEnabled(&apiKey, false); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable API key"})
 		return
 	}
@@ -121,33 +379,39 @@ func DisableAPIKey(c *gin.Context) {
 // EnableAPIKey godoc
 //
 //	@Summary		Enable API key
-//	@Description	Enable an API key by setting its is_enabled flag to true.
+//	@Description	Enable an API key by setting its is_enabled flag to true. Only admin keys can enable admin keys, and restricted keys can only enable keys within their own path restriction.
 //	@Tags			API Keys
-//	@Param			key	path	string	true	"API key value"
+//	@Param			id	path	string	true	"API key ID or prefix"
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	object{message=string}
 //	@Failure		400	{object}	object{error=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
-//	@Router			/api-keys/{key}/enable [post]
-func EnableAPIKey(c *gin.Context) {
-	if !utility.IsTokenValid(c, models.ApiKeyTypeAPI) {
+//	@Router			/api-keys/{id}/enable [post]
+func (s *Server) EnableAPIKey(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAPI) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	apiKey, err := findAPIKeyByKeyParam(c)
+	apiKey, err := s.findAPIKeyByParam(c)
 	if err != nil {
 		return
 	}
+	audit.SetTarget(c, audit.APIKeyTarget(apiKey.ID))
+	if !authorizeAPIKeyManagement(c, apiKey) {
+		return
+	}
 
 	if apiKey.IsEnabled {
 		c.JSON(http.StatusOK, gin.H{"message": "API key already enabled"})
 		return
 	}
 
-	if err := database.DB.Model(&apiKey).Update("is_enabled", true).Error; err != nil {
+	if err := s.APIKeys.SetEnabled(&apiKey, true); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable API key"})
 		return
 	}
@@ -155,40 +419,100 @@ func EnableAPIKey(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "API key enabled"})
 }
 
-// findAPIKeyByKeyParam 读取路径参数并基于 key 查找 API Key / findAPIKeyByKeyParam fetches the API key by its value
-func findAPIKeyByKeyParam(c *gin.Context) (models.ApiKey, error) {
-	keyParam := c.Param("key")
-	trimmedKey := strings.TrimSpace(keyParam)
-	if trimmedKey == "" {
+// authorizeAPIKeyManagement 检查当前 API Key 是否可以管理 target；不允许时写入 403 响应。
+// admin 密钥只能由 admin 密钥管理，受限制的调用方只能管理限制在其范围内的密钥
+// authorizeAPIKeyManagement checks that the current API key may manage target and writes a 403 response otherwise.
+// Admin keys are left to admin keys, and restricted callers can only manage keys within their own restriction
+func authorizeAPIKeyManagement(c *gin.Context, target models.ApiKey) bool {
+	caller, _ := utility.CurrentAPIKey(c)
+	if caller.HasScope(models.ScopeAdmin) {
+		return true
+	}
+	if target.HasScope(models.ScopeAdmin) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admin keys can manage admin keys"})
+		return false
+	}
+	if !caller.Confines(target) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Restricted keys can only manage keys within their own path restriction"})
+		return false
+	}
+	return true
+}
+
+// restrictAPIKeyQuery 将 api_keys 表上的查询限制在当前 API Key 可以管理的密钥内，规则与 authorizeAPIKeyManagement 相同
+// restrictAPIKeyQuery limits a query on the api_keys table to the keys the current API key may manage, following the rules of authorizeAPIKeyManagement
+func restrictAPIKeyQuery(c *gin.Context, query *gorm.DB) *gorm.DB {
+	caller, _ := utility.CurrentAPIKey(c)
+	if caller.HasScope(models.ScopeAdmin) {
+		return query
+	}
+	query = query.Where("',' || scopes || ',' NOT LIKE ?", "%,"+string(models.ScopeAdmin)+",%")
+	if caller.Visibility != "" {
+		query = query.Where("visibility = ?", caller.Visibility)
+	}
+	if caller.NamePattern != "" {
+		query = query.Where("name_pattern = ?", caller.NamePattern)
+	}
+	return query
+}
+
+// findAPIKeyByParam 读取路径参数，按 ID（纯数字）或前缀（可带 gofi_）查找 API Key / findAPIKeyByParam fetches the API key by ID (all digits) or prefix (optionally gofi_-prefixed) from the path parameter
+func (s *Server) findAPIKeyByParam(c *gin.Context) (models.ApiKey, error) {
+	param := strings.TrimSpace(c.Param("id"))
+	if param == "" {
 		errMsg := "Invalid API key"
 		c.JSON(http.StatusBadRequest, gin.H{"error": errMsg})
 		return models.ApiKey{}, errors.New(errMsg)
 	}
 
-	var apiKey models.ApiKey
-	result := database.DB.Where("key = ?", trimmedKey).First(&apiKey)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
-		return models.ApiKey{}, result.Error
+	// 以 gofi_ 开头时总是按前缀查找，这样纯数字的前缀也能被寻址
+	// A leading gofi_ always means a prefix, so all-digit prefixes stay addressable
+	query := s.APIKeys.Query()
+	if prefix, ok := strings.CutPrefix(param, models.APIKeyMarker); ok {
+		query = query.Where("prefix = ?", prefix)
+	} else if id, err := strconv.ParseUint(param, 10, 64); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("prefix = ?", param)
 	}
-	if result.Error != nil {
+
+	var apiKeys []models.ApiKey
+	if err := query.Limit(2).Find(&apiKeys).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query API key"})
-		return models.ApiKey{}, result.Error
+		return models.ApiKey{}, err
+	}
+	switch len(apiKeys) {
+	case 0:
+		c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+		return models.ApiKey{}, gorm.ErrRecordNotFound
+	case 1:
+		return apiKeys[0], nil
+	default:
+		errMsg := "Prefix matches several API keys, use the ID instead"
+		c.JSON(http.StatusConflict, gin.H{"error": errMsg})
+		return models.ApiKey{}, errors.New(errMsg)
 	}
-
-	return apiKey, nil
 }
 
-// parseAPIKeyType 将字符串解析为 ApiKeyType / parseAPIKeyType converts string into ApiKeyType
-func parseAPIKeyType(input string) (models.ApiKeyType, bool) {
-	trimmed := strings.ToLower(strings.TrimSpace(input))
-	switch models.ApiKeyType(trimmed) {
-	case models.ApiKeyTypeUpload,
-		models.ApiKeyTypeDownload,
-		models.ApiKeyTypeShorten,
-		models.ApiKeyTypeAPI:
-		return models.ApiKeyType(trimmed), true
-	default:
-		return "", false
+// newAPIKeyResponse 将 ApiKey 转换为不含密钥的响应结构 / newAPIKeyResponse converts an ApiKey into a response without the key itself
+func newAPIKeyResponse(apiKey models.ApiKey, now time.Time) ApiKeyResponse {
+	return ApiKeyResponse{
+		ID:          apiKey.ID,
+		Prefix:      apiKey.Prefix,
+		Scopes:      apiKey.ScopeList(),
+		Label:       apiKey.Label,
+		IsEnabled:   apiKey.IsEnabled,
+		IsExpired:   apiKey.IsExpired(now),
+		ExpiresAt:   apiKey.ExpiresAt,
+		LastUsedAt:  apiKey.LastUsedAt,
+		LastUsedIP:  apiKey.LastUsedIP,
+		Visibility:  apiKey.Visibility,
+		NamePattern: apiKey.NamePattern,
+		CreatedAt:   apiKey.CreatedAt,
+
+		RateLimitRequests:  apiKey.RateLimitRequests,
+		RateLimitBurst:     apiKey.RateLimitBurst,
+		RateLimitBandwidth: apiKey.RateLimitBandwidth,
+		StorageQuota:       apiKey.StorageQuota,
 	}
 }
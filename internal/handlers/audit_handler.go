@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogResponse 表示一条审计记录 / AuditLogResponse represents one audit entry
+type AuditLogResponse struct {
+	ID         uint      `json:"id"`
+	Time       time.Time `json:"time"`
+	ActorKeyID *uint     `json:"actor_key_id"` // 为空表示未携带有效密钥 / Null when no known key was presented
+	Action     string    `json:"action"`
+	Target     string    `json:"target"`
+	ClientIP   string    `json:"client_ip"`
+	UserAgent  string    `json:"user_agent"`
+	Outcome    string    `json:"outcome"`
+	Status     int       `json:"status"`
+}
+
+// AuditLogListResponse 表示分页的审计记录列表 / AuditLogListResponse represents a paginated list of audit entries
+type AuditLogListResponse struct {
+	Page
+	Items []AuditLogResponse `json:"items"`
+}
+
+// ListAuditLogs godoc
+//
+//	@Summary		Query the audit log
+//	@Description	Returns audit entries for uploads, private downloads, deletions, signed URLs and short link and API key management, newest first. Requires an `admin` key.
+//	@Tags			Audit
+//	@Produce		json
+//	@Param			from		query	string	false	"Only entries at or after this time, RFC 3339"
+//	@Param			to			query	string	false	"Only entries before this time, RFC 3339"
+//	@Param			actor		query	integer	false	"Only entries of this API key ID"
+//	@Param			action		query	string	false	"Comma-separated actions, e.g. file.upload,file.download"
+//	@Param			outcome		query	string	false	"Only entries with this outcome"	Enums(success, denied, failure)
+//	@Param			page		query	integer	false	"Page number, starting at 1"
+//	@Param			page_size	query	integer	false	"Items per page (default 50, max 500)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	AuditLogListResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/audit [get]
+//
+// ListAuditLogs 按时间范围、操作者和操作查询审计记录
+// ListAuditLogs queries audit entries by time range, actor and action
+func (s *Server) ListAuditLogs(c *gin.Context) {
+	// 1. 验证 Token；审计记录涉及所有密钥的操作，只对 admin 开放
+	// 1. Validate Token; the audit log covers every key's operations, so only admin keys may read it
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAdmin) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. 根据查询参数构建过滤条件
+	// 2. Build filters from the query parameters
+	query := s.AuditLogs.Query()
+
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+
+	if raw := c.Query("actor"); raw != "" {
+		actor, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "actor must be an API key ID"})
+			return
+		}
+		query = query.Where("actor_key_id = ?", actor)
+	}
+
+	if raw := c.Query("action"); raw != "" {
+		var actions []string
+		for _, action := range strings.Split(raw, ",") {
+			action = strings.TrimSpace(action)
+			if !slices.Contains(audit.AllActions, action) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Unsupported action " + strconv.Quote(action)})
+				return
+			}
+			actions = append(actions, action)
+		}
+		query = query.Where("action IN ?", actions)
+	}
+
+	switch outcome := c.Query("outcome"); outcome {
+	case "":
+	case audit.OutcomeSuccess, audit.OutcomeDenied, audit.OutcomeFailure:
+		query = query.Where("outcome = ?", outcome)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "outcome must be success, denied or failure"})
+		return
+	}
+
+	// 3. 统计总数并读取当前页，最新的记录在前
+	// 3. Count the total and load the current page, newest first
+	query, err = paginate(query, &page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count audit entries"})
+		return
+	}
+	var entries []models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Find(&entries).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list audit entries"})
+		return
+	}
+
+	response := AuditLogListResponse{Page: page, Items: make([]AuditLogResponse, len(entries))}
+	for i, entry := range entries {
+		response.Items[i] = AuditLogResponse{
+			ID:         entry.ID,
+			Time:       entry.CreatedAt,
+			ActorKeyID: entry.ActorKeyID,
+			Action:     entry.Action,
+			Target:     entry.Target,
+			ClientIP:   entry.ClientIP,
+			UserAgent:  entry.UserAgent,
+			Outcome:    entry.Outcome,
+			Status:     entry.Status,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"gorm.io/gorm"
+)
+
+// ConflictPolicy 定义上传文件与已有文件同名时的处理方式
+// ConflictPolicy defines what happens when an upload collides with an existing filename
+type ConflictPolicy string
+
+const (
+	ConflictReject    ConflictPolicy = "reject"    // 返回 409 / Respond with 409
+	ConflictOverwrite ConflictPolicy = "overwrite" // 原地替换内容 / Replace the content in place
+	ConflictRename    ConflictPolicy = "rename"    // 为新文件加后缀 / Give the new file a suffix
+	ConflictVersion   ConflictPolicy = "version"   // 将旧文件归档为 name.vN.ext / Archive the old file as name.vN.ext
+)
+
+// 重命名后缀样式
+// Rename suffix styles
+const (
+	RenameStyleNumeric = "numeric" // report-1.pdf
+	RenameStyleRandom  = "random"  // report-3f9a1c.pdf
+)
+
+// errFileExists 表示在 reject 策略下目标文件已存在
+// errFileExists indicates that the target file already exists under the reject policy
+var errFileExists = errors.New("file already exists")
+
+// maxNameAttempts 限制寻找空闲文件名的尝试次数
+// maxNameAttempts bounds the attempts to find a free filename
+const maxNameAttempts = 1000
+
+// uploadOptions 描述一次上传的冲突处理选项
+// uploadOptions describes the collision handling options of one upload
+type uploadOptions struct {
+	Policy      ConflictPolicy
+	RenameStyle string
+}
+
+// storedFile 是 storeFile 的结果；ArchivedAs 在 version 策略归档旧文件时非空
+// storedFile is the result of storeFile; ArchivedAs is set when the version policy archived the old file
+type storedFile struct {
+	File       models.File
+	ArchivedAs string
+}
+
+// parseUploadOptions 解析请求给出的策略，空值回退到服务器默认值
+// parseUploadOptions parses the requested policy, falling back to the server defaults for empty values
+func parseUploadOptions(config *config.Config, policy, renameStyle string) (uploadOptions, error) {
+	if policy = strings.ToLower(strings.TrimSpace(policy)); policy == "" {
+		policy = config.UploadConflictPolicy
+	}
+	if renameStyle = strings.ToLower(strings.TrimSpace(renameStyle)); renameStyle == "" {
+		renameStyle = config.UploadRenameStyle
+	}
+
+	opts := uploadOptions{Policy: ConflictPolicy(policy), RenameStyle: renameStyle}
+	switch opts.Policy {
+	case ConflictReject, ConflictOverwrite, ConflictRename, ConflictVersion:
+	default:
+		return opts, fmt.Errorf("invalid conflict policy %q", policy)
+	}
+	switch opts.RenameStyle {
+	case RenameStyleNumeric, RenameStyleRandom:
+	default:
+		return opts, fmt.Errorf("invalid rename style %q", renameStyle)
+	}
+	return opts, nil
+}
+
+// reserveFreeName 找到一个尚未使用的派生文件名并锁定它，返回名称和解锁函数
+// reserveFreeName finds an unused derived filename and locks it, returning the name and its unlock function
+func (s *Server) reserveFreeName(visibility storage.Visibility, name string, derive func(base, ext string, attempt int) (string, error)) (string, func(), error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	for attempt := 1; attempt <= maxNameAttempts; attempt++ {
+		candidate, err := derive(base, ext, attempt)
+		if err != nil {
+			return "", nil, err
+		}
+		if !storage.ValidName(candidate) {
+			return "", nil, storage.ErrInvalidName
+		}
+
+		unlock := s.nameLocks.Lock(string(visibility), candidate)
+		_, err = s.findFile(candidate, visibility)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return candidate, unlock, nil
+		}
+		unlock()
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return "", nil, fmt.Errorf("no free name found for %q", name)
+}
+
+// renamedName 根据样式生成 base-1.ext 或 base-<random>.ext
+// renamedName produces base-1.ext or base-<random>.ext depending on the style
+func renamedName(style string) func(base, ext string, attempt int) (string, error) {
+	return func(base, ext string, attempt int) (string, error) {
+		if style == RenameStyleRandom {
+			suffix, err := utility.GenerateRandomString(3)
+			if err != nil {
+				return "", err
+			}
+			return base + "-" + suffix + ext, nil
+		}
+		return fmt.Sprintf("%s-%d%s", base, attempt, ext), nil
+	}
+}
+
+// versionedName 生成 base.vN.ext
+// versionedName produces base.vN.ext
+func versionedName(base, ext string, attempt int) (string, error) {
+	return fmt.Sprintf("%s.v%d%s", base, attempt, ext), nil
+}
+
+// archiveFile 将已有文件复制到归档名称下并重命名其记录，使指向它的短链接继续提供旧内容
+// archiveFile copies an existing file to its archive name and renames its record, so short links pointing at it keep serving the old content
+func (s *Server) archiveFile(ctx context.Context, existing models.File, archiveName string) error {
+	visibility := storage.Visibility(existing.Visibility)
+
+	obj, _, err := s.Storage.Get(ctx, visibility, existing.Name)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	if _, err := s.Storage.Put(ctx, visibility, archiveName, obj); err != nil {
+		return err
+	}
+	if err := s.Files.Rename(&existing, archiveName); err != nil {
+		_ = s.Storage.Delete(ctx, visibility, archiveName)
+		return err
+	}
+	return nil
+}
+
+// restoreArchive 撤销 archiveFile：将归档的内容复制回原名称，把记录改回原名称，再删除归档对象
+// restoreArchive undoes archiveFile: it copies the archived content back to the original name, renames the record back
+// and then removes the archived object
+func (s *Server) restoreArchive(ctx context.Context, original models.File, archiveName string) error {
+	visibility := storage.Visibility(original.Visibility)
+
+	obj, _, err := s.Storage.Get(ctx, visibility, archiveName)
+	if err != nil {
+		return err
+	}
+	defer obj.Close()
+
+	if _, err := s.Storage.Put(ctx, visibility, original.Name, obj); err != nil {
+		return err
+	}
+	if err := s.Files.Rename(&original, original.Name); err != nil {
+		return err
+	}
+	return s.Storage.Delete(ctx, visibility, archiveName)
+}
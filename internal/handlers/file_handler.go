@@ -1,16 +1,67 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
-	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
 	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
 	"github.com/ShinoharaHaruna/GoFi/internal/utility"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// FileResponse 表示文件记录的响应结构 / FileResponse represents the response structure for a file record
+type FileResponse struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Visibility   string    `json:"visibility"`
+	OriginalName string    `json:"original_name"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256"`
+	MimeType     string    `json:"mime_type"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FileShortLink 概述指向某个文件的短链接 / FileShortLink summarizes a short link pointing at a file
+type FileShortLink struct {
+	ShortCode string `json:"short_code"`
+	IsEnabled bool   `json:"is_enabled"`
+}
+
+// FileListItem 表示文件列表中的一项 / FileListItem represents one entry of the file listing
+type FileListItem struct {
+	FileResponse
+	ShortLinks []FileShortLink `json:"short_links"`
+}
+
+// FileListResponse 表示分页的文件列表 / FileListResponse represents a paginated file listing
+type FileListResponse struct {
+	Page
+	Items []FileListItem `json:"items"`
+}
+
+// 删除文件时对短链接的处理方式 / How short links are handled when their file is deleted
+const (
+	CascadeDisable = "disable"
+	CascadeDelete  = "delete"
+)
+
+// fileSortColumns 将排序字段映射到数据库列 / fileSortColumns maps sort fields to database columns
+var fileSortColumns = map[string]string{
+	"name":       "name",
+	"size":       "size",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
 // UploadFile godoc
 //
 //	@Summary		Upload a file
@@ -20,75 +71,137 @@ import (
 //	@Produce		json
 //	@Param			file				formData	file	true	"File to upload"
 //	@Param			X-GoFi-Target-Dir	header		string	false	"Target directory: 'public' or 'private' (default)"	Enums(public, private)
+//	@Param			X-GoFi-On-Conflict	header		string	false	"What to do if the name is taken (defaults to UPLOAD_CONFLICT_POLICY); also accepted as form field 'on_conflict'"	Enums(reject, overwrite, rename, version)
+//	@Param			X-GoFi-Rename-Style	header		string	false	"Suffix style for 'rename' (defaults to UPLOAD_RENAME_STYLE); also accepted as form field 'rename_style'"	Enums(numeric, random)
 //	@Security		ApiKeyAuth
-//	@Success		200	{object}	object{download_path=string}
+//	@Success		200	{object}	object{download_path=string,filename=string,archived_as=string,file=FileResponse}
 //	@Failure		400	{object}	object{error=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
+//	@Failure		413	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
+//	@Failure		507	{object}	object{error=string}
 //	@Router			/upload [post]
 //
 // UploadFile 处理文件上传请求
 // UploadFile handles file upload requests
-func UploadFile(c *gin.Context) {
-	cfg, _ := c.Get("config")
-	config := cfg.(*config.Config)
-
+func (s *Server) UploadFile(c *gin.Context) {
 	// 1. 验证 Token
 	// 1. Validate Token
-	if !utility.IsTokenValid(c, models.ApiKeyTypeUpload) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeUpload) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
-	// 2. 解析 multipart/form-data
-	// 2. Parse multipart/form-data
+	// 2. 读取请求体之前按 Content-Length 检查存储配额（包含少量 multipart 开销）；此时还不知道文件名，
+	//    因此请求头或默认策略为 overwrite 时跳过，由保存时的检查减去被替换的文件
+	// 2. Check the storage quota against Content-Length before reading the body (it includes a little multipart overhead);
+	//    the filename is not known yet, so this is skipped when the header or default policy is overwrite and the check
+	//    made when saving leaves out the replaced file
+	earlyPolicy := c.GetHeader("X-GoFi-On-Conflict")
+	if earlyPolicy == "" {
+		earlyPolicy = s.Config.UploadConflictPolicy
+	}
+	mayOverwrite := strings.EqualFold(strings.TrimSpace(earlyPolicy), string(ConflictOverwrite))
+	if c.Request.ContentLength > 0 && !mayOverwrite && !s.checkStorageQuota(c, c.Request.ContentLength, nil) {
+		return
+	}
+
+	// 3. 解析 multipart/form-data
+	// 3. Parse multipart/form-data
 	file, err := c.FormFile("file")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file upload request: " + err.Error()})
 		return
 	}
 
-	// 3. 确定目标目录
-	// 3. Determine target directory
-	targetDir := c.GetHeader("X-GoFi-Target-Dir")
-	if targetDir != "public" {
-		targetDir = "private" // 默认为 private / Default to private
+	// 4. 确定目标目录
+	// 4. Determine target directory
+	visibility := storage.VisibilityPrivate // 默认为 private / Default to private
+	if c.GetHeader("X-GoFi-Target-Dir") == string(storage.VisibilityPublic) {
+		visibility = storage.VisibilityPublic
 	}
 
-	// 4. 构建并清理目标路径
-	// 4. Build and clean the destination path
+	// 5. 清理文件名
+	// 5. Clean the filename
 	// 安全措施：只使用文件名，防止路径遍历
 	// Security measure: only use the filename, prevent path traversal
 	filename := filepath.Base(file.Filename)
-	destPath := filepath.Join(config.GoFiBaseDir, targetDir, filename)
-
-	// 再次检查，确保路径不会逃逸出 base dir
-	// Double-check to ensure the path does not escape the base dir
-	if !utility.IsPathSafe(destPath, config.GoFiBaseDir) {
+	if !storage.ValidName(filename) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename or path"})
 		return
 	}
+	audit.SetTarget(c, audit.FileTarget(string(visibility), filename))
+	if !authorizeFileAccess(c, visibility, filename) {
+		return
+	}
 
-	// 5. 保存文件
-	// 5. Save the file
-	if err := c.SaveUploadedFile(file, destPath); err != nil {
+	// 6. 确定文件名冲突时的处理方式，请求头优先于表单字段
+	// 6. Determine how to handle a filename collision; headers take precedence over form fields
+	policy := c.GetHeader("X-GoFi-On-Conflict")
+	if policy == "" {
+		policy = c.PostForm("on_conflict")
+	}
+	renameStyle := c.GetHeader("X-GoFi-Rename-Style")
+	if renameStyle == "" {
+		renameStyle = c.PostForm("rename_style")
+	}
+	opts, err := parseUploadOptions(s.Config, policy, renameStyle)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 7. 保存文件
+	// 7. Save the file
+	src, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read uploaded file: " + err.Error()})
+		return
+	}
+	defer src.Close()
+
+	stored, err := s.storeFile(c, visibility, filename, filename, opts, file.Size, src)
+	if errors.Is(err, errFileExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "File already exists"})
+		return
+	}
+	if isStorageLimit(err) {
+		respondStorageError(c, err)
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file: " + err.Error()})
 		return
 	}
 
-	// 6. 返回下载路径
-	// 6. Return the download path
-	c.JSON(http.StatusOK, gin.H{"download_path": "/" + filename})
+	audit.SetTarget(c, audit.FileTarget(stored.File.Visibility, stored.File.Name))
+
+	// 8. 返回最终文件名、下载路径和文件记录
+	// 8. Return the final filename, download path and file record
+	response := gin.H{
+		"download_path": "/" + stored.File.Name,
+		"filename":      stored.File.Name,
+		"file":          newFileResponse(stored.File),
+	}
+	if stored.ArchivedAs != "" {
+		response["archived_as"] = stored.ArchivedAs
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DownloadFile godoc
 //
 //	@Summary		Download a file
-//	@Description	Downloads a file. Public files are accessible directly. For private files, a 'download' type token is required via query parameter or Authorization header.
+//	@Description	Downloads a file. Public files are accessible directly. For private files, a 'download' type token is required via query parameter or Authorization header, or a signed URL issued by POST /api/signed-urls.
 //	@Tags			Files
 //	@Produce		application/octet-stream
 //	@Param			filename	path	string	true	"Filename"
 //	@Param			token		query	string	false	"Authentication token for private files"
+//	@Param			exp			query	integer	false	"Expiry of a signed URL (Unix seconds)"
+//	@Param			sig			query	string	false	"Signature of a signed URL"
+//	@Param			bind		query	string	false	"Set to 'ip' when the signed URL is bound to a client IP"
 //	@Security		ApiKeyAuth
 //	@Success		200	{file}		file	"The requested file"
 //	@Failure		401	{object}	object{error=string}
@@ -98,51 +211,450 @@ func UploadFile(c *gin.Context) {
 //
 // DownloadFile 处理文件下载请求
 // DownloadFile handles file download requests
-func DownloadFile(c *gin.Context) {
-	cfg, _ := c.Get("config")
-	config := cfg.(*config.Config)
+func (s *Server) DownloadFile(c *gin.Context) {
 	filename := c.Param("filename")
 
 	// 安全措施：清理路径，防止遍历
 	// Security measure: clean the path to prevent traversal
 	cleanFilename := filepath.Base(filename)
+	if !storage.ValidName(cleanFilename) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
 
-	// 1. 尝试从 public 目录提供文件
-	// 1. Try to serve the file from the public directory
-	publicPath := filepath.Join(config.GoFiBaseDir, "public", cleanFilename)
-	if _, err := os.Stat(publicPath); err == nil {
-		// 安全检查：确保路径不会逃逸
-		// Security check: ensure the path does not escape
-		if !utility.IsPathSafe(publicPath, config.GoFiBaseDir) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// 带签名的 URL 代替 Token，直接提供 private 区域中的文件
+	// A signed URL replaces the Token and serves the file from the private area directly
+	if c.Query("sig") != "" {
+		audit.Mark(c, audit.ActionDownload, audit.FileTarget(string(storage.VisibilityPrivate), cleanFilename))
+		if !s.verifySignedDownload(c, cleanFilename) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid or expired signature"})
 			return
 		}
-		c.File(publicPath)
+		file, err := s.findFile(cleanFilename, storage.VisibilityPrivate)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
+			return
+		}
+		s.serveObject(c, file)
+		return
+	}
+
+	// 1. 优先提供 public 区域中的文件
+	// 1. Prefer the file in the public area
+	file, err := s.findFile(cleanFilename, storage.VisibilityPublic)
+	if err == nil {
+		s.serveObject(c, file)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
 		return
 	}
 
-	// 2. 尝试从 private 目录提供文件
-	// 2. Try to serve the file from the private directory
-	privatePath := filepath.Join(config.GoFiBaseDir, "private", cleanFilename)
-	if _, err := os.Stat(privatePath); err == nil {
+	// 2. 尝试提供 private 区域中的文件
+	// 2. Try to serve the file from the private area
+	file, err = s.findFile(cleanFilename, storage.VisibilityPrivate)
+	if err == nil {
+		audit.Mark(c, audit.ActionDownload, audit.FileTarget(file.Visibility, file.Name))
+
 		// 验证 Token
 		// Validate Token
-		if !utility.IsTokenValid(c, models.ApiKeyTypeDownload) {
+		if !utility.IsTokenValid(c, s.APIKeys, models.ScopeDownload) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
-
-		// 安全检查：确保路径不会逃逸
-		// Security check: ensure the path does not escape
-		if !utility.IsPathSafe(privatePath, config.GoFiBaseDir) {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		if !authorizeFileAccess(c, storage.VisibilityPrivate, file.Name) {
 			return
 		}
-		c.File(privatePath)
+		s.serveObject(c, file)
+		return
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
 		return
 	}
 
-	// 3. 如果文件在两个目录都不存在
-	// 3. If the file does not exist in either directory
+	// 3. 如果文件在两个区域都不存在
+	// 3. If the file does not exist in either area
 	c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 }
+
+// serveObject 从存储后端流式输出对象，支持 Range 和条件请求；配置为重定向模式时改为跳转到预签名 URL。
+// 返回是否提供了内容或跳转，出错以及 304、412 和 416 等没有内容的响应返回 false
+// serveObject streams an object from the storage backend, honouring Range and conditional requests;
+// in redirect mode it sends the client to a presigned URL instead. It returns whether the content or the redirect was sent;
+// errors and bodiless answers such as 304, 412 and 416 return false
+func (s *Server) serveObject(c *gin.Context, file models.File) bool {
+	visibility := storage.Visibility(file.Visibility)
+
+	if presigner, ok := s.Storage.(storage.Presigner); ok && s.Config.S3DownloadMode == storage.DownloadModeRedirect {
+		signedURL, err := presigner.PresignGet(c.Request.Context(), visibility, file.Name, s.Config.S3PresignExpiry)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to presign download URL"})
+			return false
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Redirect(http.StatusFound, signedURL.String())
+		return true
+	}
+
+	obj, info, err := s.Storage.Get(c.Request.Context(), visibility, file.Name)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open file"})
+		return false
+	}
+	defer obj.Close()
+
+	c.Header("Content-Type", file.MimeType)
+	c.Header("ETag", `"`+file.SHA256+`"`)
+	http.ServeContent(c.Writer, c.Request, info.Name, info.ModTime, obj)
+	return c.Writer.Status() < http.StatusMultipleChoices
+}
+
+// storeFile 按冲突策略确定最终文件名，检查存储配额，将 size 字节的内容写入存储后端，并创建或更新对应的 File 记录
+// storeFile resolves the final filename according to the conflict policy, checks the storage quotas, writes the size bytes
+// of content to the storage backend and creates or updates the matching File record
+func (s *Server) storeFile(c *gin.Context, visibility storage.Visibility, name, originalName string, opts uploadOptions, size int64, r io.Reader) (result storedFile, err error) {
+	ctx := c.Request.Context()
+
+	// 1. 锁定目标文件名，并按策略处理同名文件
+	// 1. Lock the target filename and handle an existing file according to the policy
+	unlock := s.nameLocks.Lock(string(visibility), name)
+	defer unlock()
+
+	existing, err := s.findFile(name, visibility)
+	found := err == nil
+	archiveName := ""
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// 没有冲突 / No collision
+	case err != nil:
+		return result, err
+	case opts.Policy == ConflictReject:
+		return result, errFileExists
+	case opts.Policy == ConflictRename:
+		newName, unlockNew, err := s.reserveFreeName(visibility, name, renamedName(opts.RenameStyle))
+		if err != nil {
+			return result, err
+		}
+		defer unlockNew()
+		name = newName
+	case opts.Policy == ConflictVersion:
+		newName, unlockArchive, err := s.reserveFreeName(visibility, name, versionedName)
+		if err != nil {
+			return result, err
+		}
+		defer unlockArchive()
+		archiveName = newName
+	}
+
+	// 2. 在写入任何内容之前检查配额并预留空间，直到记录保存；overwrite 策略替换的文件不再计入用量，
+	//    version 策略归档的旧文件仍然计入
+	// 2. Check the quotas and reserve the space before anything is written, until the record is saved; the file the
+	//    overwrite policy replaces no longer counts, the old file the version policy archives still does
+	var replaced *models.File
+	if found && opts.Policy == ConflictOverwrite {
+		replaced = &existing
+	}
+	release, err := s.reserveStorage(c, size, replaced)
+	if err != nil {
+		return result, err
+	}
+	defer release()
+
+	if archiveName != "" {
+		if err := s.archiveFile(ctx, existing, archiveName); err != nil {
+			return result, err
+		}
+		result.ArchivedAs = archiveName
+
+		// 新内容未能写入或保存时撤销归档，原名称继续指向旧文件；请求可能已被取消，因此不使用其上下文
+		// Undo the archive if the new content fails to be written or saved, so the name keeps pointing at the old file;
+		// the request may have been cancelled, so its context is not used
+		defer func() {
+			if err == nil {
+				return
+			}
+			if restoreErr := s.restoreArchive(context.WithoutCancel(ctx), existing, archiveName); restoreErr != nil {
+				err = errors.Join(err, restoreErr)
+			}
+		}()
+	}
+
+	// 3. 写入内容
+	// 3. Write the content
+	inspector := utility.NewContentInspector(name, r)
+	info, err := s.Storage.Put(ctx, visibility, name, inspector)
+	if err != nil {
+		return result, err
+	}
+
+	var apiKeyID *uint
+	if apiKey, ok := utility.CurrentAPIKey(c); ok {
+		apiKeyID = &apiKey.ID
+	}
+
+	// 4. 保存记录；overwrite 策略保留原记录的 ID，使指向它的短链接继续有效
+	// 4. Save the record; the overwrite policy keeps the existing ID so short links pointing at it stay valid
+	result.File = models.File{Name: name, Visibility: string(visibility)}
+	err = s.Files.Save(&result.File, map[string]any{
+		"original_name": originalName,
+		"size":          info.Size,
+		"sha256":        inspector.SHA256(),
+		"mime_type":     inspector.MimeType(),
+		"api_key_id":    apiKeyID,
+	})
+	return result, err
+}
+
+// findFile 按名称和可见性查找文件记录 / findFile looks up a file record by name and visibility
+func (s *Server) findFile(name string, visibility storage.Visibility) (models.File, error) {
+	return s.Files.Find(name, string(visibility))
+}
+
+// newFileResponse 将文件记录转换为响应结构 / newFileResponse converts a file record into its response structure
+func newFileResponse(file models.File) FileResponse {
+	return FileResponse{
+		ID:           file.ID,
+		Name:         file.Name,
+		Visibility:   file.Visibility,
+		OriginalName: file.OriginalName,
+		Size:         file.Size,
+		SHA256:       file.SHA256,
+		MimeType:     file.MimeType,
+		CreatedAt:    file.CreatedAt,
+		UpdatedAt:    file.UpdatedAt,
+	}
+}
+
+// ListFiles godoc
+//
+//	@Summary		List files
+//	@Description	Returns a paginated list of stored files with the short links pointing to each. Requires a 'list' type token.
+//	@Tags			Files
+//	@Produce		json
+//	@Param			visibility		query	string	false	"Only files in this directory"	Enums(public, private)
+//	@Param			prefix			query	string	false	"Only names starting with this prefix"
+//	@Param			glob			query	string	false	"Only names matching this glob ('*' and '?')"
+//	@Param			created_after	query	string	false	"Only files created at or after this RFC 3339 time"
+//	@Param			created_before	query	string	false	"Only files created before this RFC 3339 time"
+//	@Param			min_size		query	integer	false	"Minimum size in bytes"
+//	@Param			max_size		query	integer	false	"Maximum size in bytes"
+//	@Param			sort			query	string	false	"Sort field (default created_at)"	Enums(name, size, created_at, updated_at)
+//	@Param			order			query	string	false	"Sort order (default desc)"			Enums(asc, desc)
+//	@Param			page			query	integer	false	"Page number, starting at 1"
+//	@Param			page_size		query	integer	false	"Items per page (default 50, max 500)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	FileListResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/files [get]
+//
+// ListFiles 分页列出文件记录
+// ListFiles lists file records page by page
+func (s *Server) ListFiles(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeList) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析分页、排序和过滤参数
+	// 2. Parse pagination, sorting and filter parameters
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orderBy, err := parseSort(c, fileSortColumns, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := restrictFileQuery(c, s.Files.Query())
+	if raw := c.Query("visibility"); raw != "" {
+		visibility, ok := storage.ParseVisibility(raw)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "visibility must be public or private"})
+			return
+		}
+		query = query.Where("visibility = ?", visibility)
+	}
+	if prefix := c.Query("prefix"); prefix != "" {
+		query = query.Where(`name LIKE ? ESCAPE '\'`, prefixToLike(prefix))
+	}
+	if glob := c.Query("glob"); glob != "" {
+		query = query.Where(`name LIKE ? ESCAPE '\'`, globToLike(glob))
+	}
+
+	createdAfter, err := parseTimeQuery(c, "created_after")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdAfter != nil {
+		query = query.Where("created_at >= ?", *createdAfter)
+	}
+	createdBefore, err := parseTimeQuery(c, "created_before")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdBefore != nil {
+		query = query.Where("created_at < ?", *createdBefore)
+	}
+
+	minSize, err := parseInt64Query(c, "min_size")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if minSize != nil {
+		query = query.Where("size >= ?", *minSize)
+	}
+	maxSize, err := parseInt64Query(c, "max_size")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if maxSize != nil {
+		query = query.Where("size <= ?", *maxSize)
+	}
+
+	// 3. 查询当前页
+	// 3. Query the current page
+	query, err = paginate(query, &page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count files"})
+		return
+	}
+	var files []models.File
+	if err := query.Order(orderBy).Order("id").Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list files"})
+		return
+	}
+
+	// 4. 一次性加载指向这些文件的短链接
+	// 4. Load the short links pointing at these files in one query
+	fileIDs := make([]uint, len(files))
+	for i, file := range files {
+		fileIDs[i] = file.ID
+	}
+	var shortLinks []models.ShortLink
+	if len(fileIDs) > 0 {
+		if shortLinks, err = s.ShortLinks.ListByFileIDs(fileIDs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list short links"})
+			return
+		}
+	}
+	linksByFile := make(map[uint][]FileShortLink)
+	for _, link := range shortLinks {
+		linksByFile[*link.FileID] = append(linksByFile[*link.FileID], FileShortLink{
+			ShortCode: link.ShortCode,
+			IsEnabled: link.IsEnabled,
+		})
+	}
+
+	response := FileListResponse{Page: page, Items: make([]FileListItem, len(files))}
+	for i, file := range files {
+		links := linksByFile[file.ID]
+		if links == nil {
+			links = []FileShortLink{}
+		}
+		response.Items[i] = FileListItem{FileResponse: newFileResponse(file), ShortLinks: links}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// DeleteFile godoc
+//
+//	@Summary		Delete a file
+//	@Description	Deletes a stored file and disables (default) or deletes every short link pointing to it. Requires a 'delete' type token.
+//	@Tags			Files
+//	@Produce		json
+//	@Param			name		path	string	true	"Filename"
+//	@Param			visibility	query	string	true	"Directory the file lives in"	Enums(public, private)
+//	@Param			cascade		query	string	false	"What to do with short links (default disable)"	Enums(disable, delete)
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	object{message=string,file=FileResponse,cascade=string,short_links_affected=int}
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/files/{name} [delete]
+//
+// DeleteFile 删除文件及其记录，并级联处理指向它的短链接
+// DeleteFile removes a file and its record, cascading to the short links pointing at it
+func (s *Server) DeleteFile(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeDelete) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析参数
+	// 2. Parse parameters
+	name := c.Param("name")
+	visibility, ok := storage.ParseVisibility(c.Query("visibility"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "visibility must be public or private"})
+		return
+	}
+	cascade := c.DefaultQuery("cascade", CascadeDisable)
+	if cascade != CascadeDisable && cascade != CascadeDelete {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cascade must be disable or delete"})
+		return
+	}
+	audit.SetTarget(c, audit.FileTarget(string(visibility), name))
+	if !authorizeFileAccess(c, visibility, name) {
+		return
+	}
+
+	unlock := s.nameLocks.Lock(string(visibility), name)
+	defer unlock()
+
+	file, err := s.findFile(name, visibility)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
+		return
+	}
+
+	// 3. 在同一事务中处理短链接、删除记录和存储对象；存储删除失败时回滚
+	// 3. Handle short links, the record and the stored object in one transaction; roll back if the storage delete fails
+	affected, err := s.Files.Delete(file, cascade == CascadeDelete, func() error {
+		err := s.Storage.Delete(c.Request.Context(), visibility, name)
+		if errors.Is(err, storage.ErrNotFound) {
+			// 对象已不存在，仍然清理记录 / The object is already gone; still clean up the record
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "File deleted",
+		"file":                 newFileResponse(file),
+		"cascade":              cascade,
+		"short_links_affected": affected,
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 分页参数的默认值和上限
+// Defaults and limits for pagination parameters
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// Page 是分页列表响应的公共字段
+// Page holds the common fields of a paginated list response
+type Page struct {
+	Page     int   `json:"page"`
+	PageSize int   `json:"page_size"`
+	Total    int64 `json:"total"`
+}
+
+// parsePagination 解析 page 和 page_size 查询参数
+// parsePagination parses the page and page_size query parameters
+func parsePagination(c *gin.Context) (Page, error) {
+	p := Page{Page: 1, PageSize: defaultPageSize}
+
+	if raw := c.Query("page"); raw != "" {
+		page, err := strconv.Atoi(raw)
+		if err != nil || page < 1 {
+			return p, errors.New("page must be a positive integer")
+		}
+		p.Page = page
+	}
+	if raw := c.Query("page_size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size < 1 || size > maxPageSize {
+			return p, errors.New("page_size must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		p.PageSize = size
+	}
+	return p, nil
+}
+
+// paginate 统计总数后将查询限制在当前页
+// paginate counts the total and then limits the query to the current page
+func paginate(query *gorm.DB, p *Page) (*gorm.DB, error) {
+	if err := query.Count(&p.Total).Error; err != nil {
+		return nil, err
+	}
+	return query.Offset((p.Page - 1) * p.PageSize).Limit(p.PageSize), nil
+}
+
+// parseTimeQuery 解析 RFC 3339 时间查询参数并转换为 UTC，缺省时返回 nil
+// parseTimeQuery parses an RFC 3339 time query parameter and converts it to UTC, returning nil when absent
+func parseTimeQuery(c *gin.Context, key string) (*time.Time, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, errors.New(key + " must be an RFC 3339 timestamp")
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// parseInt64Query 解析非负整数查询参数，缺省时返回 nil
+// parseInt64Query parses a non-negative integer query parameter, returning nil when absent
+func parseInt64Query(c *gin.Context, key string) (*int64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 0 {
+		return nil, errors.New(key + " must be a non-negative integer")
+	}
+	return &v, nil
+}
+
+// parseBoolQuery 解析布尔查询参数，缺省时返回 nil
+// parseBoolQuery parses a boolean query parameter, returning nil when absent
+func parseBoolQuery(c *gin.Context, key string) (*bool, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	v, err := strconv.ParseBool(raw)
+	if err != nil {
+		return nil, errors.New(key + " must be a boolean")
+	}
+	return &v, nil
+}
+
+// parseSort 校验排序字段和方向，返回可直接用于 ORDER BY 的子句
+// parseSort validates the sort field and direction and returns a clause safe for ORDER BY
+func parseSort(c *gin.Context, allowed map[string]string, defaultField string) (string, error) {
+	field := c.DefaultQuery("sort", defaultField)
+	column, ok := allowed[field]
+	if !ok {
+		return "", errors.New("unsupported sort field " + strconv.Quote(field))
+	}
+
+	switch order := strings.ToLower(c.DefaultQuery("order", "desc")); order {
+	case "asc", "desc":
+		return column + " " + order, nil
+	default:
+		return "", errors.New("order must be asc or desc")
+	}
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符
+// likeEscaper escapes wildcards in a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// prefixToLike 将前缀转换为 LIKE 模式（配合 ESCAPE '\' 使用）
+// prefixToLike turns a prefix into a LIKE pattern (to be used with ESCAPE '\')
+func prefixToLike(prefix string) string {
+	return likeEscaper.Replace(prefix) + "%"
+}
+
+// globToLike 将 glob 模式（* 和 ?）转换为 LIKE 模式（配合 ESCAPE '\' 使用）
+// globToLike turns a glob pattern (* and ?) into a LIKE pattern (to be used with ESCAPE '\')
+func globToLike(glob string) string {
+	escaped := likeEscaper.Replace(glob)
+	return strings.NewReplacer("*", "%", "?", "_").Replace(escaped)
+}
+
+// resolveExpiry 根据绝对时间 expiresAt 或相对时长 expiresIn 计算 UTC 过期时间，两者不能同时指定
+// resolveExpiry works out a UTC expiry time from an absolute expiresAt or a relative expiresIn; they are mutually exclusive
+func resolveExpiry(expiresAt *time.Time, expiresIn string, now time.Time) (*time.Time, error) {
+	switch {
+	case expiresAt != nil && expiresIn != "":
+		return nil, errors.New("expires_at and expires_in are mutually exclusive")
+	case expiresAt != nil:
+		if !expiresAt.After(now) {
+			return nil, errors.New("expires_at must be in the future")
+		}
+		at := expiresAt.UTC()
+		return &at, nil
+	case expiresIn != "":
+		d, err := time.ParseDuration(expiresIn)
+		if err != nil || d <= 0 {
+			return nil, errors.New("expires_in must be a positive duration such as \"24h\"")
+		}
+		at := now.Add(d).UTC()
+		return &at, nil
+	default:
+		return nil, nil
+	}
+}
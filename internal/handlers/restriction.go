@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errPathRestricted 是路径限制拒绝访问时的错误信息
+// errPathRestricted is the error message when the path restriction denies access
+const errPathRestricted = "API key is not allowed to access this file"
+
+// validateNamePattern 检查路径限制的文件名 glob；只支持 * 和 ?，以便在 SQL 中精确地按同一规则过滤
+// validateNamePattern checks the filename glob of a path restriction; only * and ? are supported so SQL filters follow exactly the same rules
+func validateNamePattern(pattern string) error {
+	if len(pattern) > 255 {
+		return errors.New("name_pattern must be at most 255 bytes")
+	}
+	if strings.ContainsAny(pattern, `/\[]`) || strings.ContainsRune(pattern, 0) {
+		return errors.New("name_pattern may only use '*' and '?' as wildcards and cannot contain '/', '\\', '[' or ']'")
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return errors.New("invalid name_pattern: " + err.Error())
+	}
+	return nil
+}
+
+// authorizeFileAccess 检查当前 API Key 的路径限制是否允许访问文件；不允许时写入 403 响应。
+// 未经 Token 验证的请求（如公开下载）不受限制。
+// authorizeFileAccess checks that the current API key's path restriction allows access to the file and writes a 403 response otherwise.
+// Requests without a validated Token (such as public downloads) are not restricted.
+func authorizeFileAccess(c *gin.Context, visibility storage.Visibility, name string) bool {
+	apiKey, ok := utility.CurrentAPIKey(c)
+	if !ok || apiKey.AllowsFile(string(visibility), name) {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": errPathRestricted})
+	return false
+}
+
+// authorizeShortLinkAccess 检查当前 API Key 是否可以管理短链接；文件已被删除的链接只能由不受限制的密钥管理
+// authorizeShortLinkAccess checks that the current API key may manage the short link; links whose file was deleted are left to unrestricted keys
+func authorizeShortLinkAccess(c *gin.Context, shortLink models.ShortLink) bool {
+	apiKey, ok := utility.CurrentAPIKey(c)
+	if !ok || !apiKey.IsRestricted() {
+		return true
+	}
+	if shortLink.File == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": errPathRestricted})
+		return false
+	}
+	return authorizeFileAccess(c, storage.Visibility(shortLink.File.Visibility), shortLink.File.Name)
+}
+
+// restrictedVisibility 返回当前 API Key 被限制的区域，不受限制时为空；
+// 按名称查找文件时用它代替默认的 private 优先，避免选中密钥无权访问的同名文件
+// restrictedVisibility returns the area the current API key is restricted to, or empty when unrestricted;
+// lookups by name use it instead of the private-first default so they do not pick a same-named file the key cannot access
+func restrictedVisibility(c *gin.Context) storage.Visibility {
+	apiKey, _ := utility.CurrentAPIKey(c)
+	return storage.Visibility(apiKey.Visibility)
+}
+
+// restrictFileQuery 将 files 表上的查询限制在当前 API Key 可访问的文件内
+// restrictFileQuery limits a query on the files table to the files the current API key may access
+func restrictFileQuery(c *gin.Context, query *gorm.DB) *gorm.DB {
+	apiKey, ok := utility.CurrentAPIKey(c)
+	if !ok {
+		return query
+	}
+	if apiKey.Visibility != "" {
+		query = query.Where("visibility = ?", apiKey.Visibility)
+	}
+	if apiKey.NamePattern != "" {
+		query = query.Where(`name LIKE ? ESCAPE '\'`, globToLike(apiKey.NamePattern))
+	}
+	return query
+}
+
+// restrictShortLinkQuery 将 short_links 表上的查询限制在当前 API Key 可访问的文件的链接内
+// restrictShortLinkQuery limits a query on the short_links table to links of files the current API key may access
+func (s *Server) restrictShortLinkQuery(c *gin.Context, query *gorm.DB) *gorm.DB {
+	apiKey, ok := utility.CurrentAPIKey(c)
+	if !ok || !apiKey.IsRestricted() {
+		return query
+	}
+	fileIDs := restrictFileQuery(c, s.Files.Query().Select("id"))
+	return query.Where("file_id IN (?)", fileIDs)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/analytics"
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/tus"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"gorm.io/gorm"
+)
+
+// Server 持有处理请求所需的配置、存储后端和数据访问对象，处理器是它的方法；
+// 每个 Server 互不共享状态，因此同一进程中可以运行多个实例
+// Server holds the configuration, storage backend and repositories that requests need, and the handlers are its methods;
+// servers share no state, so several instances can run in one process
+type Server struct {
+	Config  *config.Config
+	Storage storage.Backend
+	Tus     *tus.Store
+
+	Files      *repository.FileRepo
+	ShortLinks *repository.ShortLinkRepo
+	APIKeys    *repository.ApiKeyRepo
+	AuditLogs  *repository.AuditLogRepo
+
+	Hits  *analytics.Recorder
+	Audit *audit.Logger
+
+	nameLocks     utility.NameLocks
+	reservations  storageReservations
+	stopTusExpiry func()
+}
+
+// NewServer 使用已迁移的数据库 db 和存储后端创建 Server，并启动短链接访问记录器和审计记录器；不再使用时需调用 Close
+// NewServer creates a Server on the migrated database db and the storage backend, starting the short link hit recorder
+// and the audit logger; call Close once it is no longer used
+func NewServer(cfg *config.Config, db *gorm.DB, store storage.Backend) (*Server, error) {
+	// 可续传上传的临时数据默认保存在基础目录下
+	// Resumable upload scratch data lives under the base directory by default
+	tusDir := cfg.TusUploadDir
+	if tusDir == "" {
+		tusDir = filepath.Join(cfg.GoFiBaseDir, ".uploads")
+	}
+
+	s := &Server{
+		Config:     cfg,
+		Storage:    store,
+		Tus:        tus.NewStore(tusDir),
+		Files:      repository.NewFileRepo(db),
+		ShortLinks: repository.NewShortLinkRepo(db),
+		APIKeys:    repository.NewApiKeyRepo(db),
+		AuditLogs:  repository.NewAuditLogRepo(db),
+	}
+
+	auditLogger, err := audit.NewLogger(s.AuditLogs, s.APIKeys, cfg.AuditLogFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log file: %w", err)
+	}
+	s.Audit = auditLogger
+	s.Hits = analytics.NewRecorder(s.ShortLinks)
+
+	// 定期删除长时间没有进展的上传，最多每小时检查一次
+	// Periodically remove uploads that stopped making progress, checking at most hourly
+	if cfg.TusUploadExpiry > 0 {
+		s.stopTusExpiry = s.Tus.ExpireEvery(min(cfg.TusUploadExpiry, time.Hour), cfg.TusUploadExpiry)
+	}
+	return s, nil
+}
+
+// Close 停止清理过期上传，写入剩余的短链接访问记录并关闭审计镜像文件
+// Close stops removing expired uploads, flushes the remaining short link hits and closes the audit mirror file
+func (s *Server) Close() {
+	if s.stopTusExpiry != nil {
+		s.stopTusExpiry()
+	}
+	s.Hits.Close()
+	s.Audit.Close()
+}
@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/config"
-	"github.com/ShinoharaHaruna/GoFi/internal/database"
+	"github.com/ShinoharaHaruna/GoFi/internal/analytics"
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
 	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
 	"github.com/ShinoharaHaruna/GoFi/internal/utility"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,7 +21,12 @@ import (
 // CreateShortLinkRequest 定义了创建短链接的请求体结构
 // CreateShortLinkRequest defines the request body structure for creating a short link
 type CreateShortLinkRequest struct {
-	Filename string `json:"filename" binding:"required"`
+	Filename     string     `json:"filename" binding:"required"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`    // RFC 3339 过期时间 / RFC 3339 expiry time
+	ExpiresIn    string     `json:"expires_in,omitempty"`    // 相对过期时间，如 "24h" / Relative expiry, e.g. "24h"
+	MaxDownloads *int64     `json:"max_downloads,omitempty"` // 最大下载次数 / Maximum number of downloads
+	Password     string     `json:"password,omitempty"`      // 访问密码 / Access password
+	Alias        string     `json:"alias,omitempty"`         // 自定义短代码 / Custom short code
 }
 
 // DisableShortLink godoc
@@ -29,34 +38,39 @@ type CreateShortLinkRequest struct {
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	object{message=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
 //	@Router			/shorten/{shortcode} [delete]
-func DisableShortLink(c *gin.Context) {
-	if !utility.IsTokenValid(c, models.ApiKeyTypeShorten) {
+func (s *Server) DisableShortLink(c *gin.Context) {
+	audit.SetTarget(c, audit.ShortLinkTarget(c.Param("shortcode")))
+
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	shortcode := c.Param("shortcode")
 
-	var shortLink models.ShortLink
-	result := database.DB.Where("short_code = ?", shortcode).First(&shortLink)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	shortLink, err := s.ShortLinks.FindByCode(shortcode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
 		return
 	}
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link"})
 		return
 	}
+	if !authorizeShortLinkAccess(c, shortLink) {
+		return
+	}
 
 	if !shortLink.IsEnabled {
 		c.JSON(http.StatusOK, gin.H{"message": "Short link already disabled"})
 		return
 	}
 
-	if err := database.DB.Model(&shortLink).Update("is_enabled", false).Error; err != nil {
+	if err := s.ShortLinks.SetEnabled(&shortLink, false); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable short link"})
 		return
 	}
@@ -73,34 +87,39 @@ func DisableShortLink(c *gin.Context) {
 //	@Security		ApiKeyAuth
 //	@Success		200	{object}	object{message=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
 //	@Router			/shorten/{shortcode}/enable [post]
-func EnableShortLink(c *gin.Context) {
-	if !utility.IsTokenValid(c, models.ApiKeyTypeShorten) {
+func (s *Server) EnableShortLink(c *gin.Context) {
+	audit.SetTarget(c, audit.ShortLinkTarget(c.Param("shortcode")))
+
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
 
 	shortcode := c.Param("shortcode")
 
-	var shortLink models.ShortLink
-	result := database.DB.Where("short_code = ?", shortcode).First(&shortLink)
-	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+	shortLink, err := s.ShortLinks.FindByCode(shortcode)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
 		return
 	}
-	if result.Error != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link"})
 		return
 	}
+	if !authorizeShortLinkAccess(c, shortLink) {
+		return
+	}
 
 	if shortLink.IsEnabled {
 		c.JSON(http.StatusOK, gin.H{"message": "Short link already enabled"})
 		return
 	}
 
-	if err := database.DB.Model(&shortLink).Update("is_enabled", true).Error; err != nil {
+	if err := s.ShortLinks.SetEnabled(&shortLink, true); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable short link"})
 		return
 	}
@@ -117,22 +136,21 @@ func EnableShortLink(c *gin.Context) {
 //	@Produce		json
 //	@Param			request	body	CreateShortLinkRequest	true	"Request body containing the filename"
 //	@Security		ApiKeyAuth
-//	@Success		200	{object}	object{short_url_path=string}
+//	@Success		200	{object}	object{short_url_path=string,expires_at=string,max_downloads=int}
 //	@Failure		400	{object}	object{error=string}
 //	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
 //	@Failure		404	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
 //	@Failure		500	{object}	object{error=string}
 //	@Router			/shorten [post]
 //
 // CreateShortLink 创建一个新的短链接
 // CreateShortLink creates a new short link
-func CreateShortLink(c *gin.Context) {
-	cfg, _ := c.Get("config")
-	config := cfg.(*config.Config)
-
+func (s *Server) CreateShortLink(c *gin.Context) {
 	// 1. 验证 Token
 	// 1. Validate Token
-	if !utility.IsTokenValid(c, models.ApiKeyTypeShorten) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
@@ -145,122 +163,294 @@ func CreateShortLink(c *gin.Context) {
 		return
 	}
 
+	expiresAt, err := req.expiry(time.Now())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.MaxDownloads != nil && *req.MaxDownloads < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "max_downloads must be at least 1"})
+		return
+	}
+	var passwordHash string
+	if req.Password != "" {
+		if passwordHash, err = hashShortLinkPassword(req.Password); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// 3. 检查文件是否存在并确定其隐私状态
 	// 3. Check if file exists and determine its privacy status
 	cleanFilename := filepath.Clean(req.Filename)
-	publicPath := filepath.Join(config.GoFiBaseDir, "public", cleanFilename)
-	privatePath := filepath.Join(config.GoFiBaseDir, "private", cleanFilename)
-
-	// 检查文件是否存在于任何一个目录中
-	// Check if the file exists in either directory
-	_, errPublic := os.Stat(publicPath)
-	_, errPrivate := os.Stat(privatePath)
+	if !storage.ValidName(cleanFilename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
+		return
+	}
 
-	if os.IsNotExist(errPublic) && os.IsNotExist(errPrivate) {
+	file, err := s.findLinkTarget(cleanFilename, restrictedVisibility(c))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
 		return
 	}
-
-	// 确定文件是否为私有
-	// Determine if the file is private
-	isPrivate := !os.IsNotExist(errPrivate)
-
-	// 4. 生成唯一的短代码
-	// 4. Generate a unique short code
-	shortCode, err := utility.GenerateUniqueShortCode(5)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate short code"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
 		return
 	}
+	if !authorizeFileAccess(c, storage.Visibility(file.Visibility), file.Name) {
+		return
+	}
+
+	// 4. 使用自定义别名或生成唯一的短代码
+	// 4. Use the custom alias or generate a unique short code
+	shortCode := req.Alias
+	if shortCode != "" {
+		if err := validateAlias(shortCode); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if taken, err := s.shortCodeExists(shortCode); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link"})
+			return
+		} else if taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "Alias is already in use"})
+			return
+		}
+	} else {
+		alphabet, err := utility.ResolveAlphabet(s.Config.ShortCodeAlphabet)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid short code alphabet"})
+			return
+		}
+		if shortCode, err = utility.GenerateUniqueShortCode(s.ShortLinks, alphabet, s.Config.ShortCodeLength); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate short code"})
+			return
+		}
+	}
 
 	// 5. 创建数据库记录
 	// 5. Create database record
 	shortLink := models.ShortLink{
 		ShortCode:        shortCode,
-		OriginalFilename: cleanFilename,
-		IsPrivate:        isPrivate,
+		FileID:           &file.ID,
+		OriginalFilename: file.Name,
+		IsPrivate:        file.IsPrivate(),
 		IsEnabled:        true, // 默认启用 / Enabled by default
+		ExpiresAt:        expiresAt,
+		MaxDownloads:     req.MaxDownloads,
+		PasswordHash:     passwordHash,
+	}
+	if apiKey, ok := utility.CurrentAPIKey(c); ok {
+		shortLink.CreatedByKeyID = &apiKey.ID
 	}
 
-	if result := database.DB.Create(&shortLink); result.Error != nil {
+	if err := s.ShortLinks.Create(&shortLink); err != nil {
+		// 并发创建同一别名时，唯一索引会拒绝后来者
+		// When the same alias is created concurrently, the unique index rejects the later one
+		if taken, err := s.shortCodeExists(shortCode); err == nil && taken {
+			c.JSON(http.StatusConflict, gin.H{"error": "Alias is already in use"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save short link"})
 		return
 	}
+	audit.SetTarget(c, audit.ShortLinkTarget(shortCode))
 
 	// 6. 返回短链接 URL
 	// 6. Return the short link URL
 	// 注意：这里的 URL 应该由客户端根据自己的域名构建，服务器只提供路径
 	// Note: The URL here should be constructed by the client based on its own domain, the server only provides the path
-	c.JSON(http.StatusOK, gin.H{"short_url_path": "/s/" + shortCode})
+	c.JSON(http.StatusOK, gin.H{
+		"short_url_path": "/s/" + shortCode,
+		"expires_at":     shortLink.ExpiresAt,
+		"max_downloads":  shortLink.MaxDownloads,
+	})
 }
 
 // DownloadFileFromShortLink godoc
 //
 //	@Summary		Download a file from a short link
-//	@Description	Downloads a file using a short code. If the original file is private, a 'download' type token is required.
+//	@Description	Downloads a file using a short code. If the link has a password, it must be given via the X-GoFi-Link-Password header or the unlock cookie (browsers get an HTML form); otherwise a private link requires a 'download' type token. Expired or used-up links answer 410 Gone. HEAD requests and failed downloads do not count against max_downloads.
 //	@Tags			Short Links
 //	@Produce		application/octet-stream
 //	@Param			shortcode	path		string	true	"Short code of the file"
 //	@Param			token		query		string	false	"Authentication token for private files"
+//	@Param			X-GoFi-Link-Password	header	string	false	"Password of a password-protected link"
 //	@Success		200			{file}		file	"The requested file"
 //	@Failure		401			{object}	object{error=string}
+//	@Failure		403			{object}	object{error=string}
 //	@Failure		404			{object}	object{error=string}
+//	@Failure		410			{object}	object{error=string}
 //	@Failure		500			{object}	object{error=string}
 //	@Router			/s/{shortcode} [get]
+//	@Router			/s/{shortcode} [head]
 //
 // DownloadFileFromShortLink 处理通过短链接下载文件的请求
 // DownloadFileFromShortLink handles file download requests via short link
-func DownloadFileFromShortLink(c *gin.Context) {
-	cfg, _ := c.Get("config")
-	config := cfg.(*config.Config)
-	shortCode := c.Param("shortcode")
-
-	// 1. 在数据库中查找短链接
-	// 1. Find the short link in the database
-	var shortLink models.ShortLink
-	if result := database.DB.Where("short_code = ?", shortCode).First(&shortLink); result.Error != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
-		return
+func (s *Server) DownloadFileFromShortLink(c *gin.Context) {
+	// 1. 查找短链接并检查其状态；只要短链接存在，响应结束后都会记录本次访问
+	// 1. Find the short link and check its state; once the link exists, the visit is recorded after the response
+	shortLink, ok := s.loadShortLink(c, c.Param("shortcode"))
+	if shortLink.ID != 0 {
+		defer s.recordShortLinkHit(c, shortLink)
 	}
-
-	// 2. 检查短链接是否启用
-	// 2. Check if the short link is enabled
-	if !shortLink.IsEnabled {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Short link is disabled"})
+	if !ok {
 		return
 	}
+	if shortLink.File.IsPrivate() {
+		audit.Mark(c, audit.ActionDownload, audit.FileTarget(shortLink.File.Visibility, shortLink.File.Name))
+	}
 
-	// 3. 如果是私有文件，验证 Token
-	// 3. If it's a private file, validate the Token
-	if shortLink.IsPrivate {
-		if !utility.IsTokenValid(c, models.ApiKeyTypeDownload) {
+	// 2. 受密码保护的链接以密码代替下载 Token；否则私有链接需要验证 Token
+	// 2. A password-protected link takes the password instead of a download Token; otherwise private links need a Token
+	if shortLink.HasPassword() {
+		if !s.authorizeShortLinkPassword(c, shortLink) {
+			return
+		}
+	} else if shortLink.EffectiveVisibility() == string(storage.VisibilityPrivate) {
+		if !utility.IsTokenValid(c, s.APIKeys, models.ScopeDownload) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			return
 		}
+		if !authorizeShortLinkAccess(c, shortLink) {
+			return
+		}
 	}
 
-	// 4. 构建文件路径并提供下载
-	// 4. Build the file path and serve the download
-	var filePath string
-	if shortLink.IsPrivate {
-		filePath = filepath.Join(config.GoFiBaseDir, "private", shortLink.OriginalFilename)
-	} else {
-		filePath = filepath.Join(config.GoFiBaseDir, "public", shortLink.OriginalFilename)
+	// 3. HEAD 请求不传输内容，因此不占用下载次数
+	// 3. HEAD requests transfer no content, so they claim no download
+	if c.Request.Method == http.MethodHead {
+		s.serveObject(c, *shortLink.File)
+		return
 	}
 
-	// 安全检查：确保最终路径仍在 base dir 内
-	// Security check: ensure the final path is still within the base dir
-	if !utility.IsPathSafe(filePath, config.GoFiBaseDir) {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	// 4. 原子地占用一次下载次数，保证一次性链接只能成功下载一次；未能提供内容时归还
+	// 4. Atomically claim one download, so a one-time link really serves once; give it back when nothing was served
+	claimed, err := s.ShortLinks.ClaimDownload(shortLink.ID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update short link"})
+		return
+	}
+	if !claimed {
+		c.JSON(http.StatusGone, gin.H{"error": "Short link download limit reached"})
 		return
 	}
 
-	// 检查文件是否存在
-	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	if !s.serveObject(c, *shortLink.File) {
+		if err := s.ShortLinks.ReleaseDownload(shortLink.ID); err != nil {
+			c.Error(err)
+		}
+	}
+}
+
+// expiry 根据 expires_at 或 expires_in 计算过期时间，两者不能同时指定
+// expiry works out the expiry time from expires_at or expires_in; they are mutually exclusive
+func (req CreateShortLinkRequest) expiry(now time.Time) (*time.Time, error) {
+	return resolveExpiry(req.ExpiresAt, req.ExpiresIn, now)
+}
+
+// loadShortLink 查找短链接并检查其是否启用、未过期且指向的文件仍存在；失败时写入响应
+// loadShortLink finds a short link and checks that it is enabled, unexpired and its file still exists; it writes the response on failure
+func (s *Server) loadShortLink(c *gin.Context, shortCode string) (models.ShortLink, bool) {
+	shortLink, err := s.ShortLinks.FindByCode(shortCode)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
+		return shortLink, false
+	}
+
+	if !shortLink.IsEnabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short link is disabled"})
+		return shortLink, false
+	}
+
+	if shortLink.File == nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Original file not found"})
-		return
+		return shortLink, false
+	}
+
+	if shortLink.IsExpired(time.Now()) {
+		c.JSON(http.StatusGone, gin.H{"error": "Short link has expired"})
+		return shortLink, false
+	}
+	if shortLink.IsExhausted() {
+		c.JSON(http.StatusGone, gin.H{"error": "Short link download limit reached"})
+		return shortLink, false
+	}
+
+	return shortLink, true
+}
+
+// aliasPattern 限定别名由字母、数字、'-' 和 '_' 组成，且首尾为字母或数字
+// aliasPattern restricts aliases to letters, digits, '-' and '_', starting and ending with a letter or digit
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9](?:[A-Za-z0-9_-]*[A-Za-z0-9])?$`)
+
+// reservedAliases 是不能用作别名的词，避免与路由或常见路径混淆
+// reservedAliases are words that cannot be used as aliases, so they are never confused with routes or common paths
+var reservedAliases = map[string]bool{
+	"admin":    true,
+	"api":      true,
+	"api-keys": true,
+	"health":   true,
+	"s":        true,
+	"shorten":  true,
+	"static":   true,
+	"swagger":  true,
+	"upload":   true,
+	"uploads":  true,
+	"uuid":     true,
+}
+
+// validateAlias 检查自定义别名的长度、字符集和保留词
+// validateAlias checks a custom alias against the length bounds, charset and reserved words
+func validateAlias(alias string) error {
+	if len(alias) < utility.MinShortCodeLength || len(alias) > utility.MaxShortCodeLength {
+		return fmt.Errorf("alias must be between %d and %d characters", utility.MinShortCodeLength, utility.MaxShortCodeLength)
 	}
+	if !aliasPattern.MatchString(alias) {
+		return errors.New("alias may only contain letters, digits, '-' and '_', and must start and end with a letter or digit")
+	}
+	if reservedAliases[strings.ToLower(alias)] {
+		return fmt.Errorf("alias %q is reserved", alias)
+	}
+	return nil
+}
 
-	c.File(filePath)
+// shortCodeExists 判断短代码是否已被占用
+// shortCodeExists reports whether a short code is already taken
+func (s *Server) shortCodeExists(shortCode string) (bool, error) {
+	return s.ShortLinks.CodeExists(shortCode)
+}
+
+// recordShortLinkHit 将本次访问的结果异步写入访问记录
+// recordShortLinkHit asynchronously records the outcome of this visit
+func (s *Server) recordShortLinkHit(c *gin.Context, shortLink models.ShortLink) {
+	// 只统计成功响应中发送的文件内容，不计错误信息和表单页面
+	// Only count file content sent in successful responses, not error messages or the form page
+	status := c.Writer.Status()
+	var bytesServed int64
+	if status >= 200 && status < 300 {
+		bytesServed = int64(max(c.Writer.Size(), 0))
+	}
+
+	s.Hits.Record(models.ShortLinkHit{
+		ShortLinkID: shortLink.ID,
+		ClientIP:    analytics.AnonymizeIP(c.ClientIP(), s.Config.ShortLinkHitIPMode),
+		UserAgent:   utility.Truncate(c.Request.UserAgent(), 512),
+		Referrer:    utility.Truncate(c.Request.Referer(), 1024),
+		Status:      status,
+		BytesServed: bytesServed,
+	})
+}
+
+// findLinkTarget 查找短链接要指向的文件；未指定可见性时，与之前按目录检查的行为一致，优先使用 private 区域中的文件
+// findLinkTarget finds the file a short link should point at; without a visibility it prefers the private file, as the former directory check did
+func (s *Server) findLinkTarget(name string, visibility storage.Visibility) (models.File, error) {
+	if visibility != "" {
+		return s.findFile(name, visibility)
+	}
+	file, err := s.findFile(name, storage.VisibilityPrivate)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		file, err = s.findFile(name, storage.VisibilityPublic)
+	}
+	return file, err
 }
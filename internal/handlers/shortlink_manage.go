@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// VisibilityInherit 表示短链接沿用所指文件的可见性
+// VisibilityInherit means the short link inherits the visibility of its file
+const VisibilityInherit = "inherit"
+
+// ShortLinkResponse 表示短链接的详细信息
+// ShortLinkResponse represents the details of a short link
+type ShortLinkResponse struct {
+	ShortCode          string        `json:"short_code"`
+	ShortURLPath       string        `json:"short_url_path"`
+	File               *FileResponse `json:"file"` // 文件已被删除时为空 / Null when the file was deleted
+	OriginalFilename   string        `json:"original_filename"`
+	Visibility         string        `json:"visibility"`          // 实际生效的可见性 / Effective visibility
+	VisibilityOverride string        `json:"visibility_override"` // 为空表示沿用文件 / Empty means inherited from the file
+	IsEnabled          bool          `json:"is_enabled"`
+	HasPassword        bool          `json:"has_password"`
+	ExpiresAt          *time.Time    `json:"expires_at"`
+	MaxDownloads       *int64        `json:"max_downloads"`
+	DownloadCount      int64         `json:"download_count"`
+	CreatedByKeyID     *uint         `json:"created_by_key_id"`
+	CreatedAt          time.Time     `json:"created_at"`
+}
+
+// ShortLinkListResponse 表示分页的短链接列表
+// ShortLinkListResponse represents a paginated short link listing
+type ShortLinkListResponse struct {
+	Page
+	Items []ShortLinkResponse `json:"items"`
+}
+
+// UpdateShortLinkRequest 定义了修改短链接的请求体结构，未提供的字段保持不变
+// UpdateShortLinkRequest defines the request body for updating a short link; omitted fields stay unchanged
+type UpdateShortLinkRequest struct {
+	Filename       *string `json:"filename,omitempty"`        // 重新指向的文件 / File to repoint the link at
+	FileVisibility string  `json:"file_visibility,omitempty"` // 在哪个区域查找该文件，默认优先 private / Area to look the file up in, private first by default
+	Visibility     *string `json:"visibility,omitempty"`      // public、private 或 inherit / public, private or inherit
+}
+
+// shortLinkSortColumns 将排序字段映射到数据库列 / shortLinkSortColumns maps sort fields to database columns
+var shortLinkSortColumns = map[string]string{
+	"short_code":     "short_code",
+	"created_at":     "created_at",
+	"download_count": "download_count",
+}
+
+// ListShortLinks godoc
+//
+//	@Summary		List short links
+//	@Description	Returns a paginated list of short links. Requires a 'shorten' type token.
+//	@Tags			Short Links
+//	@Produce		json
+//	@Param			filename		query	string	false	"Only links to files with this name ('*' and '?' act as wildcards)"
+//	@Param			enabled			query	boolean	false	"Only enabled or disabled links"
+//	@Param			created_by		query	integer	false	"Only links created with this API key ID"
+//	@Param			created_after	query	string	false	"Only links created at or after this RFC 3339 time"
+//	@Param			created_before	query	string	false	"Only links created before this RFC 3339 time"
+//	@Param			sort			query	string	false	"Sort field (default created_at)"	Enums(short_code, created_at, download_count)
+//	@Param			order			query	string	false	"Sort order (default desc)"			Enums(asc, desc)
+//	@Param			page			query	integer	false	"Page number, starting at 1"
+//	@Param			page_size		query	integer	false	"Items per page (default 50, max 500)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	ShortLinkListResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/shortlinks [get]
+//
+// ListShortLinks 分页列出短链接
+// ListShortLinks lists short links page by page
+func (s *Server) ListShortLinks(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析分页、排序和过滤参数
+	// 2. Parse pagination, sorting and filter parameters
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	orderBy, err := parseSort(c, shortLinkSortColumns, "created_at")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := s.restrictShortLinkQuery(c, s.ShortLinks.Query())
+	if filename := c.Query("filename"); filename != "" {
+		fileIDs := s.Files.Query().Select("id").Where(`name LIKE ? ESCAPE '\'`, globToLike(filename))
+		query = query.Where("file_id IN (?)", fileIDs)
+	}
+
+	enabled, err := parseBoolQuery(c, "enabled")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if enabled != nil {
+		query = query.Where("is_enabled = ?", *enabled)
+	}
+
+	createdBy, err := parseInt64Query(c, "created_by")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdBy != nil {
+		query = query.Where("created_by_key_id = ?", *createdBy)
+	}
+
+	createdAfter, err := parseTimeQuery(c, "created_after")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdAfter != nil {
+		query = query.Where("created_at >= ?", *createdAfter)
+	}
+	createdBefore, err := parseTimeQuery(c, "created_before")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if createdBefore != nil {
+		query = query.Where("created_at < ?", *createdBefore)
+	}
+
+	// 3. 查询当前页
+	// 3. Query the current page
+	query, err = paginate(query, &page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count short links"})
+		return
+	}
+	var shortLinks []models.ShortLink
+	if err := query.Preload("File").Order(orderBy).Order("id").Find(&shortLinks).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list short links"})
+		return
+	}
+
+	response := ShortLinkListResponse{Page: page, Items: make([]ShortLinkResponse, len(shortLinks))}
+	for i, shortLink := range shortLinks {
+		response.Items[i] = newShortLinkResponse(shortLink)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetShortLink godoc
+//
+//	@Summary		Get short link details
+//	@Description	Returns the details of a short link. Requires a 'shorten' type token.
+//	@Tags			Short Links
+//	@Produce		json
+//	@Param			code	path	string	true	"Short code"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	ShortLinkResponse
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/shortlinks/{code} [get]
+//
+// GetShortLink 返回短链接的详细信息
+// GetShortLink returns the details of a short link
+func (s *Server) GetShortLink(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	shortLink, ok := s.findShortLinkByCode(c, c.Param("code"))
+	if !ok || !authorizeShortLinkAccess(c, shortLink) {
+		return
+	}
+	c.JSON(http.StatusOK, newShortLinkResponse(shortLink))
+}
+
+// UpdateShortLink godoc
+//
+//	@Summary		Update a short link
+//	@Description	Repoints a short link at another file and/or changes its visibility. Requires a 'shorten' type token; making a private file public through the link also requires the 'download' scope.
+//	@Tags			Short Links
+//	@Accept			json
+//	@Produce		json
+//	@Param			code	path	string					true	"Short code"
+//	@Param			request	body	UpdateShortLinkRequest	true	"Fields to change"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	ShortLinkResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/shortlinks/{code} [patch]
+//
+// UpdateShortLink 修改短链接指向的文件或可见性
+// UpdateShortLink changes the file or visibility of a short link
+func (s *Server) UpdateShortLink(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析请求
+	// 2. Parse Request
+	var req UpdateShortLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+	if req.Filename == nil && req.Visibility == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Nothing to update: set filename and/or visibility"})
+		return
+	}
+
+	audit.SetTarget(c, audit.ShortLinkTarget(c.Param("code")))
+	shortLink, ok := s.findShortLinkByCode(c, c.Param("code"))
+	if !ok || !authorizeShortLinkAccess(c, shortLink) {
+		return
+	}
+	updates := make(map[string]any)
+
+	// 3. 重新指向另一个文件
+	// 3. Repoint the link at another file
+	if req.Filename != nil {
+		fileVisibility := restrictedVisibility(c)
+		if req.FileVisibility != "" {
+			var valid bool
+			if fileVisibility, valid = storage.ParseVisibility(req.FileVisibility); !valid {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "file_visibility must be public or private"})
+				return
+			}
+		}
+
+		name := filepath.Clean(*req.Filename)
+		if !storage.ValidName(name) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
+			return
+		}
+		file, err := s.findLinkTarget(name, fileVisibility)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
+			return
+		}
+		if !authorizeFileAccess(c, storage.Visibility(file.Visibility), file.Name) {
+			return
+		}
+
+		updates["file_id"] = file.ID
+		updates["legacy_unlinked"] = false
+		updates["original_filename"] = file.Name
+		updates["is_private"] = file.IsPrivate()
+		shortLink.File = &file
+	}
+
+	// 4. 修改可见性
+	// 4. Change the visibility
+	if req.Visibility != nil {
+		switch *req.Visibility {
+		case VisibilityInherit:
+			shortLink.Visibility = ""
+		case string(storage.VisibilityPublic), string(storage.VisibilityPrivate):
+			shortLink.Visibility = *req.Visibility
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "visibility must be public, private or inherit"})
+			return
+		}
+		updates["visibility"] = shortLink.Visibility
+	}
+
+	// 5. 让私有文件经短链接公开下载等同于下载它，因此需要 download 权限
+	// 5. Letting a private file be downloaded publicly through the link amounts to downloading it, so it needs the download scope
+	if shortLink.File != nil && shortLink.File.IsPrivate() && shortLink.EffectiveVisibility() == string(storage.VisibilityPublic) {
+		if apiKey, _ := utility.CurrentAPIKey(c); !apiKey.HasScope(models.ScopeDownload) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Making a private file public requires the download scope"})
+			return
+		}
+	}
+
+	if err := s.ShortLinks.Update(&shortLink, updates); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update short link"})
+		return
+	}
+	c.JSON(http.StatusOK, newShortLinkResponse(shortLink))
+}
+
+// findShortLinkByCode 按短代码查找短链接（含文件）；失败时写入响应
+// findShortLinkByCode finds a short link (with its file) by code; it writes the response on failure
+func (s *Server) findShortLinkByCode(c *gin.Context, code string) (models.ShortLink, bool) {
+	shortLink, err := s.ShortLinks.FindByCode(code)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
+		return shortLink, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link"})
+		return shortLink, false
+	}
+	return shortLink, true
+}
+
+// newShortLinkResponse 将短链接记录转换为响应结构
+// newShortLinkResponse converts a short link record into its response structure
+func newShortLinkResponse(shortLink models.ShortLink) ShortLinkResponse {
+	response := ShortLinkResponse{
+		ShortCode:          shortLink.ShortCode,
+		ShortURLPath:       "/s/" + shortLink.ShortCode,
+		OriginalFilename:   shortLink.OriginalFilename,
+		Visibility:         shortLink.EffectiveVisibility(),
+		VisibilityOverride: shortLink.Visibility,
+		IsEnabled:          shortLink.IsEnabled,
+		HasPassword:        shortLink.HasPassword(),
+		ExpiresAt:          shortLink.ExpiresAt,
+		MaxDownloads:       shortLink.MaxDownloads,
+		DownloadCount:      shortLink.DownloadCount,
+		CreatedByKeyID:     shortLink.CreatedByKeyID,
+		CreatedAt:          shortLink.CreatedAt,
+	}
+	if shortLink.File != nil {
+		file := newFileResponse(*shortLink.File)
+		response.File = &file
+	}
+	return response
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ShortLinkPasswordHeader 是脚本提交短链接密码所用的请求头
+// ShortLinkPasswordHeader is the request header scripts use to submit a short link password
+const ShortLinkPasswordHeader = "X-GoFi-Link-Password"
+
+// shortLinkCookiePrefix 是解锁 Cookie 名称的前缀，后接短代码
+// shortLinkCookiePrefix prefixes the unlock cookie name, followed by the short code
+const shortLinkCookiePrefix = "gofi_link_"
+
+// passwordFormTemplate 是浏览器访问受密码保护的短链接时显示的表单
+// passwordFormTemplate is the form shown to browsers visiting a password-protected short link
+var passwordFormTemplate = template.Must(template.New("password").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Password required - GoFi</title>
+<style>
+body { font-family: system-ui, sans-serif; display: flex; justify-content: center; margin-top: 15vh; }
+form { display: flex; flex-direction: column; gap: .75rem; width: 18rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<form method="post" action="/s/{{.ShortCode}}">
+<h1>Password required</h1>
+<p>This link is password protected.</p>
+{{if .Error}}<p class="error">{{.Error}}</p>{{end}}
+<input type="password" name="password" placeholder="Password" autofocus required>
+<button type="submit">Download</button>
+</form>
+</body>
+</html>
+`))
+
+// hashShortLinkPassword 使用 bcrypt 对短链接密码进行哈希
+// hashShortLinkPassword hashes a short link password with bcrypt
+func hashShortLinkPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if errors.Is(err, bcrypt.ErrPasswordTooLong) {
+		return "", errors.New("password must be at most 72 bytes")
+	}
+	return string(hash), err
+}
+
+// checkShortLinkPassword 判断 password 是否与短链接的密码匹配
+// checkShortLinkPassword reports whether password matches the short link's password
+func checkShortLinkPassword(shortLink models.ShortLink, password string) bool {
+	return password != "" && bcrypt.CompareHashAndPassword([]byte(shortLink.PasswordHash), []byte(password)) == nil
+}
+
+// authorizeShortLinkPassword 检查解锁 Cookie 或密码请求头；未通过时写入响应（浏览器得到密码表单）并返回 false
+// authorizeShortLinkPassword checks the unlock cookie or the password header; on failure it writes the response (a password form for browsers) and returns false
+func (s *Server) authorizeShortLinkPassword(c *gin.Context, shortLink models.ShortLink) bool {
+	if cookie, err := c.Cookie(shortLinkCookiePrefix + shortLink.ShortCode); err == nil && validUnlockCookie(s.Config, shortLink, cookie) {
+		return true
+	}
+
+	if password := c.GetHeader(ShortLinkPasswordHeader); password != "" {
+		if checkShortLinkPassword(shortLink, password) {
+			return true
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		return false
+	}
+
+	if wantsHTML(c) {
+		renderPasswordForm(c, shortLink, "")
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Password required"})
+	}
+	return false
+}
+
+// UnlockShortLink godoc
+//
+//	@Summary		Unlock a password-protected short link
+//	@Description	Checks the submitted password and sets a short-lived signed cookie, then redirects back to the short link.
+//	@Tags			Short Links
+//	@Accept			x-www-form-urlencoded
+//	@Produce		html
+//	@Param			shortcode	path		string	true	"Short code of the file"
+//	@Param			password	formData	string	true	"Short link password"
+//	@Success		303
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		410	{object}	object{error=string}
+//	@Router			/s/{shortcode} [post]
+//
+// UnlockShortLink 处理密码表单的提交
+// UnlockShortLink handles the submission of the password form
+func (s *Server) UnlockShortLink(c *gin.Context) {
+	shortLink, ok := s.loadShortLink(c, c.Param("shortcode"))
+	if !ok {
+		return
+	}
+
+	target := "/s/" + shortLink.ShortCode
+	if !shortLink.HasPassword() {
+		c.Redirect(http.StatusSeeOther, target)
+		return
+	}
+
+	password := c.PostForm("password")
+	if password == "" {
+		password = c.GetHeader(ShortLinkPasswordHeader)
+	}
+	if !checkShortLinkPassword(shortLink, password) {
+		if wantsHTML(c) {
+			renderPasswordForm(c, shortLink, "Incorrect password, please try again.")
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		}
+		return
+	}
+
+	ttl := s.Config.ShortLinkUnlockTTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	expires := strconv.FormatInt(time.Now().Add(ttl).Unix(), 10)
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(
+		shortLinkCookiePrefix+shortLink.ShortCode,
+		expires+"."+unlockSignature(s.Config, shortLink, expires),
+		int(ttl.Seconds()),
+		target,
+		"",
+		c.Request.TLS != nil,
+		true,
+	)
+	c.Redirect(http.StatusSeeOther, target)
+}
+
+// unlockSignature 对解锁 Cookie 签名；签名包含密码哈希，修改密码后旧 Cookie 失效
+// unlockSignature signs an unlock cookie; the password hash is included so changing the password revokes old cookies
+func unlockSignature(config *config.Config, shortLink models.ShortLink, expires string) string {
+	return utility.Sign(config.SecretKey, "shortlink-unlock", shortLink.ShortCode, expires, shortLink.PasswordHash)
+}
+
+// validUnlockCookie 校验解锁 Cookie 的签名和有效期
+// validUnlockCookie verifies the signature and lifetime of an unlock cookie
+func validUnlockCookie(config *config.Config, shortLink models.ShortLink, value string) bool {
+	expires, signature, ok := strings.Cut(value, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+	return utility.VerifySignature(config.SecretKey, signature, "shortlink-unlock", shortLink.ShortCode, expires, shortLink.PasswordHash)
+}
+
+// wantsHTML 判断请求是否来自期望 HTML 页面的浏览器
+// wantsHTML reports whether the request comes from a browser expecting an HTML page
+func wantsHTML(c *gin.Context) bool {
+	return strings.Contains(c.GetHeader("Accept"), "text/html")
+}
+
+// renderPasswordForm 以 401 状态返回密码表单
+// renderPasswordForm responds with the password form and status 401
+func renderPasswordForm(c *gin.Context, shortLink models.ShortLink, errMsg string) {
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusUnauthorized)
+	_ = passwordFormTemplate.Execute(c.Writer, struct {
+		ShortCode string
+		Error     string
+	}{shortLink.ShortCode, errMsg})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultStatsWindow 是未指定 from 时统计的时间范围
+// defaultStatsWindow is the period covered when from is not given
+const defaultStatsWindow = 30 * 24 * time.Hour
+
+// ShortLinkDailyStats 表示某一天（UTC）的访问汇总
+// ShortLinkDailyStats represents the visit totals of one day (UTC)
+type ShortLinkDailyStats struct {
+	Date        string `json:"date"`
+	Hits        int64  `json:"hits"`
+	Downloads   int64  `json:"downloads"`
+	BytesServed int64  `json:"bytes_served"`
+	UniqueIPs   int64  `json:"unique_ips"`
+}
+
+// ShortLinkStatsResponse 表示短链接在某段时间内的访问统计
+// ShortLinkStatsResponse represents the visit statistics of a short link over a period
+type ShortLinkStatsResponse struct {
+	ShortCode     string                `json:"short_code"`
+	DownloadCount int64                 `json:"download_count"` // 创建以来的下载次数 / Downloads since creation
+	From          time.Time             `json:"from"`
+	To            time.Time             `json:"to"`
+	Hits          int64                 `json:"hits"`
+	Downloads     int64                 `json:"downloads"` // 成功（2xx）的访问 / Successful (2xx) visits
+	BytesServed   int64                 `json:"bytes_served"`
+	UniqueIPs     int64                 `json:"unique_ips"`
+	FirstHitAt    *time.Time            `json:"first_hit_at"`
+	LastHitAt     *time.Time            `json:"last_hit_at"`
+	Days          []ShortLinkDailyStats `json:"days"`
+}
+
+// GetShortLinkStats godoc
+//
+//	@Summary		Short link statistics
+//	@Description	Returns visit totals and per-day aggregates (UTC) of a short link. Requires a 'shorten' type token.
+//	@Tags			Short Links
+//	@Produce		json
+//	@Param			code	path	string	true	"Short code"
+//	@Param			from	query	string	false	"Start of the period, RFC 3339 (default 30 days ago)"
+//	@Param			to		query	string	false	"End of the period, RFC 3339 (default now)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	ShortLinkStatsResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/shortlinks/{code}/stats [get]
+//
+// GetShortLinkStats 返回短链接的访问统计
+// GetShortLinkStats returns the visit statistics of a short link
+func (s *Server) GetShortLinkStats(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeShorten) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析时间范围
+	// 2. Parse the period
+	to, err := parseTimeQuery(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if to == nil {
+		now := time.Now().UTC()
+		to = &now
+	}
+	from, err := parseTimeQuery(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from == nil {
+		start := to.Add(-defaultStatsWindow)
+		from = &start
+	}
+	if !from.Before(*to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+
+	// 3. 查找短链接
+	// 3. Find the short link
+	shortLink, err := s.ShortLinks.FindByCode(c.Param("code"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Short link not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link"})
+		return
+	}
+	if !authorizeShortLinkAccess(c, shortLink) {
+		return
+	}
+
+	// 4. 读取范围内的访问记录并按天汇总；在 Go 中汇总以便与数据库方言无关
+	// 4. Load the hits in range and aggregate them per day; aggregating in Go keeps it independent of the SQL dialect
+	hits, err := s.ShortLinks.Hits(shortLink.ID, *from, *to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query short link hits"})
+		return
+	}
+
+	c.JSON(http.StatusOK, aggregateShortLinkHits(shortLink, from.UTC(), to.UTC(), hits))
+}
+
+// aggregateShortLinkHits 汇总按时间排序的访问记录
+// aggregateShortLinkHits aggregates hits sorted by time
+func aggregateShortLinkHits(shortLink models.ShortLink, from, to time.Time, hits []models.ShortLinkHit) ShortLinkStatsResponse {
+	response := ShortLinkStatsResponse{
+		ShortCode:     shortLink.ShortCode,
+		DownloadCount: shortLink.DownloadCount,
+		From:          from,
+		To:            to,
+		Days:          []ShortLinkDailyStats{},
+	}
+
+	days := make(map[string]*ShortLinkDailyStats)
+	dayIPs := make(map[string]map[string]bool)
+	allIPs := make(map[string]bool)
+	for _, hit := range hits {
+		date := hit.CreatedAt.UTC().Format(time.DateOnly)
+		day, ok := days[date]
+		if !ok {
+			day = &ShortLinkDailyStats{Date: date}
+			days[date] = day
+			dayIPs[date] = make(map[string]bool)
+		}
+
+		day.Hits++
+		day.BytesServed += hit.BytesServed
+		if hit.Status >= 200 && hit.Status < 300 {
+			day.Downloads++
+		}
+		if hit.ClientIP != "" {
+			dayIPs[date][hit.ClientIP] = true
+			allIPs[hit.ClientIP] = true
+		}
+	}
+
+	for date, day := range days {
+		day.UniqueIPs = int64(len(dayIPs[date]))
+		response.Hits += day.Hits
+		response.Downloads += day.Downloads
+		response.BytesServed += day.BytesServed
+		response.Days = append(response.Days, *day)
+	}
+	sort.Slice(response.Days, func(i, j int) bool { return response.Days[i].Date < response.Days[j].Date })
+	response.UniqueIPs = int64(len(allIPs))
+
+	if len(hits) > 0 {
+		first, last := hits[0].CreatedAt.UTC(), hits[len(hits)-1].CreatedAt.UTC()
+		response.FirstHitAt, response.LastHitAt = &first, &last
+	}
+	return response
+}
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// defaultSignedURLExpiry 是未指定 expires_in 时签名 URL 的有效期
+// defaultSignedURLExpiry is the lifetime of a signed URL when expires_in is not given
+const defaultSignedURLExpiry = time.Hour
+
+// signedDownloadPurpose 区分下载签名与其他用途的签名
+// signedDownloadPurpose separates download signatures from signatures made for other purposes
+const signedDownloadPurpose = "signed-download"
+
+// CreateSignedURLRequest 定义了签发下载 URL 的请求体结构
+// CreateSignedURLRequest defines the request body for issuing a signed download URL
+type CreateSignedURLRequest struct {
+	Filename  string `json:"filename" binding:"required"`
+	ExpiresIn string `json:"expires_in,omitempty"` // 有效期，如 "30m"，默认 1h / Lifetime such as "30m", default 1h
+	BindIP    bool   `json:"bind_ip,omitempty"`    // 只允许指定 IP 使用 / Only usable from one IP
+	ClientIP  string `json:"client_ip,omitempty"`  // 绑定的 IP，默认为请求方 IP / IP to bind, defaults to the requester's
+}
+
+// CreateSignedURL godoc
+//
+//	@Summary		Issue a signed download URL
+//	@Description	Issues a time-limited URL for a private file that works without an API key. The URL is signed with the server secret and optionally bound to a client IP. Requires a 'download' type token.
+//	@Tags			Files
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body	CreateSignedURLRequest	true	"File and lifetime"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	object{url_path=string,expires_at=string}
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/signed-urls [post]
+//
+// CreateSignedURL 为私有文件签发限时下载 URL
+// CreateSignedURL issues a time-limited download URL for a private file
+func (s *Server) CreateSignedURL(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeDownload) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析请求
+	// 2. Parse Request
+	var req CreateSignedURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		return
+	}
+
+	expiry := defaultSignedURLExpiry
+	if req.ExpiresIn != "" {
+		d, err := time.ParseDuration(req.ExpiresIn)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in must be a positive duration such as \"30m\""})
+			return
+		}
+		expiry = d
+	}
+	if s.Config.SignedURLMaxExpiry > 0 && expiry > s.Config.SignedURLMaxExpiry {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expires_in exceeds the maximum of " + s.Config.SignedURLMaxExpiry.String()})
+		return
+	}
+
+	var clientIP string
+	if req.BindIP || req.ClientIP != "" {
+		clientIP = c.ClientIP()
+		if req.ClientIP != "" {
+			addr, err := netip.ParseAddr(req.ClientIP)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "client_ip must be an IP address"})
+				return
+			}
+			clientIP = addr.String()
+		}
+	}
+
+	// 3. 签名 URL 只用于私有文件，公开文件无需授权即可下载
+	// 3. Signed URLs are only for private files; public files can be downloaded without authorization
+	name := filepath.Clean(req.Filename)
+	if !storage.ValidName(name) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename"})
+		return
+	}
+	audit.SetTarget(c, audit.FileTarget(string(storage.VisibilityPrivate), name))
+	if !authorizeFileAccess(c, storage.VisibilityPrivate, name) {
+		return
+	}
+	if _, err := s.findFile(name, storage.VisibilityPrivate); errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
+		return
+	}
+
+	// 4. 生成签名
+	// 4. Sign
+	expiresAt := time.Now().Add(expiry).Truncate(time.Second)
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+	path := "/" + name
+
+	query := url.Values{}
+	query.Set("exp", exp)
+	if clientIP != "" {
+		query.Set("bind", "ip")
+	}
+	query.Set("sig", signDownload(s.Config, path, exp, clientIP))
+
+	c.JSON(http.StatusOK, gin.H{
+		"url_path":   (&url.URL{Path: path, RawQuery: query.Encode()}).String(),
+		"expires_at": expiresAt.UTC(),
+	})
+}
+
+// verifySignedDownload 校验请求中的下载签名；只有签名有效且未过期时返回 true
+// verifySignedDownload checks the download signature of the request; it returns true only for a valid, unexpired signature
+func (s *Server) verifySignedDownload(c *gin.Context, name string) bool {
+	exp := c.Query("exp")
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= unix {
+		return false
+	}
+
+	var clientIP string
+	switch c.Query("bind") {
+	case "":
+	case "ip":
+		clientIP = c.ClientIP()
+		if addr, err := netip.ParseAddr(clientIP); err == nil {
+			clientIP = addr.String()
+		}
+	default:
+		return false
+	}
+
+	return utility.VerifySignature(s.Config.SecretKey, c.Query("sig"), signedDownloadPurpose, "/"+name, exp, clientIP)
+}
+
+// signDownload 对下载路径、过期时间和可选的客户端 IP 签名
+// signDownload signs the download path, expiry and optional client IP
+func signDownload(config *config.Config, path, exp, clientIP string) string {
+	return utility.Sign(config.SecretKey, signedDownloadPurpose, path, exp, clientIP)
+}
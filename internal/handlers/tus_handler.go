@@ -0,0 +1,468 @@
+package handlers
+
+import (
+	"errors"
+	"hash"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/tus"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TusResumable 为所有 tus 响应添加 Tus-Resumable 头，并拒绝不支持的协议版本
+// TusResumable adds the Tus-Resumable header to every tus response and rejects unsupported protocol versions
+func TusResumable(c *gin.Context) {
+	c.Header("Tus-Resumable", tus.Version)
+
+	// OPTIONS 请求用于协议发现，不要求携带版本头
+	// OPTIONS requests are used for discovery and need not carry the version header
+	if c.Request.Method != http.MethodOptions && c.GetHeader("Tus-Resumable") != tus.Version {
+		c.Header("Tus-Version", tus.Version)
+		c.AbortWithStatusJSON(http.StatusPreconditionFailed, gin.H{"error": "Unsupported tus version"})
+		return
+	}
+	c.Next()
+}
+
+// TusOptions godoc
+//
+//	@Summary		Discover tus capabilities
+//	@Description	Returns the supported tus version, extensions, checksum algorithms and maximum upload size.
+//	@Tags			Resumable Uploads
+//	@Success		204
+//	@Header			204	{string}	Tus-Version				"Supported protocol versions"
+//	@Header			204	{string}	Tus-Extension			"Supported extensions"
+//	@Header			204	{string}	Tus-Checksum-Algorithm	"Supported checksum algorithms"
+//	@Header			204	{integer}	Tus-Max-Size			"Maximum upload size in bytes, if limited"
+//	@Router			/uploads [options]
+//
+// TusOptions 返回服务器支持的 tus 能力
+// TusOptions reports the tus capabilities of the server
+func (s *Server) TusOptions(c *gin.Context) {
+	c.Header("Tus-Version", tus.Version)
+	c.Header("Tus-Extension", tus.Extensions)
+	c.Header("Tus-Checksum-Algorithm", tus.ChecksumAlgorithms)
+	if s.Config.TusMaxSize > 0 {
+		c.Header("Tus-Max-Size", strconv.FormatInt(s.Config.TusMaxSize, 10))
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// CreateTusUpload godoc
+//
+//	@Summary		Create a resumable upload
+//	@Description	Creates a tus upload. `Upload-Metadata` must contain `filename` (or `name`) and may contain `visibility` (`public` or `private`, default `private`). Requires an 'upload' type token.
+//	@Tags			Resumable Uploads
+//	@Param			Tus-Resumable		header	string	true	"Protocol version"	Enums(1.0.0)
+//	@Param			Upload-Length		header	integer	true	"Total size of the upload in bytes"
+//	@Param			Upload-Metadata		header	string	true	"Comma-separated key/base64-value pairs"
+//	@Param			X-GoFi-Target-Dir	header	string	false	"Target directory when not given in metadata"	Enums(public, private)
+//	@Param			X-GoFi-On-Conflict	header	string	false	"What to do if the name is taken when the upload completes; also accepted as metadata 'on_conflict'"	Enums(reject, overwrite, rename, version)
+//	@Param			X-GoFi-Rename-Style	header	string	false	"Suffix style for 'rename'; also accepted as metadata 'rename_style'"	Enums(numeric, random)
+//	@Security		ApiKeyAuth
+//	@Success		201
+//	@Header			201	{string}	Location	"URL of the created upload"
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		403	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
+//	@Failure		412	{object}	object{error=string}
+//	@Failure		413	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Failure		507	{object}	object{error=string}
+//	@Router			/uploads [post]
+//
+// CreateTusUpload 实现 tus creation 扩展
+// CreateTusUpload implements the tus creation extension
+func (s *Server) CreateTusUpload(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeUpload) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	// 2. 解析上传长度（不支持 Upload-Defer-Length）
+	// 2. Parse the upload length (Upload-Defer-Length is not supported)
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing Upload-Length"})
+		return
+	}
+	if s.Config.TusMaxSize > 0 && length > s.Config.TusMaxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Upload exceeds Tus-Max-Size"})
+		return
+	}
+
+	// 3. 解析元数据，确定文件名和目标区域
+	// 3. Parse metadata to determine the filename and target area
+	metadata, err := tus.ParseMetadata(c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Upload-Metadata: " + err.Error()})
+		return
+	}
+
+	rawName := metadata["filename"]
+	if rawName == "" {
+		rawName = metadata["name"]
+	}
+	// 安全措施：只使用文件名，防止路径遍历
+	// Security measure: only use the filename, prevent path traversal
+	filename := filepath.Base(rawName)
+	if rawName == "" || !storage.ValidName(filename) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid filename or path"})
+		return
+	}
+
+	targetDir := metadata["visibility"]
+	if targetDir == "" {
+		targetDir = c.GetHeader("X-GoFi-Target-Dir")
+	}
+	visibility := storage.VisibilityPrivate // 默认为 private / Default to private
+	if targetDir == string(storage.VisibilityPublic) {
+		visibility = storage.VisibilityPublic
+	}
+	if !authorizeFileAccess(c, visibility, filename) {
+		return
+	}
+
+	// 4. 确定文件名冲突时的处理方式，reject 策略下尽早拒绝；检查存储配额时不计入 overwrite 策略将替换的文件
+	// 4. Determine how to handle a filename collision and reject early under the reject policy; the storage quota check
+	//    leaves out the file the overwrite policy would replace
+	policy := c.GetHeader("X-GoFi-On-Conflict")
+	if policy == "" {
+		policy = metadata["on_conflict"]
+	}
+	renameStyle := c.GetHeader("X-GoFi-Rename-Style")
+	if renameStyle == "" {
+		renameStyle = metadata["rename_style"]
+	}
+	opts, err := parseUploadOptions(s.Config, policy, renameStyle)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	existing, err := s.findFile(filename, visibility)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query file"})
+		return
+	}
+	var replaced *models.File
+	if err == nil {
+		switch opts.Policy {
+		case ConflictReject:
+			c.JSON(http.StatusConflict, gin.H{"error": "File already exists"})
+			return
+		case ConflictOverwrite:
+			replaced = &existing
+		}
+	}
+	if !s.checkStorageQuota(c, length, replaced) {
+		return
+	}
+
+	// 5. 创建上传
+	// 5. Create the upload
+	id, err := utility.GenerateRandomString(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate upload ID"})
+		return
+	}
+
+	apiKey, _ := utility.CurrentAPIKey(c)
+	upload := &tus.Upload{
+		ID:          id,
+		Length:      length,
+		Metadata:    c.GetHeader("Upload-Metadata"),
+		Filename:    filename,
+		Visibility:  visibility,
+		OnConflict:  string(opts.Policy),
+		RenameStyle: opts.RenameStyle,
+		APIKeyID:    apiKey.ID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.Tus.Create(upload); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create upload"})
+		return
+	}
+
+	c.Header("Location", "/uploads/"+id)
+
+	// 空文件无需 PATCH，直接完成
+	// Empty files need no PATCH and complete immediately
+	if length == 0 && !s.completeTusUpload(c, upload) {
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// GetTusUploadOffset godoc
+//
+//	@Summary		Get resumable upload offset
+//	@Description	Returns how many bytes of the upload the server has received. Requires an 'upload' type token; only the key that created the upload can access it.
+//	@Tags			Resumable Uploads
+//	@Param			id				path	string	true	"Upload ID"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version"	Enums(1.0.0)
+//	@Security		ApiKeyAuth
+//	@Success		200
+//	@Header			200	{integer}	Upload-Offset	"Bytes received so far"
+//	@Header			200	{integer}	Upload-Length	"Total size of the upload"
+//	@Failure		401
+//	@Failure		404
+//	@Router			/uploads/{id} [head]
+//
+// GetTusUploadOffset 实现 tus HEAD 请求
+// GetTusUploadOffset implements the tus HEAD request
+func (s *Server) GetTusUploadOffset(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeUpload) {
+		c.Status(http.StatusUnauthorized)
+		return
+	}
+
+	upload, err := s.findTusUpload(c, c.Param("id"))
+	if errors.Is(err, tus.ErrNotFound) {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	c.Status(http.StatusOK)
+}
+
+// PatchTusUpload godoc
+//
+//	@Summary		Append to a resumable upload
+//	@Description	Appends the request body at `Upload-Offset`. When the last byte arrives the file is stored in its target directory. An optional `Upload-Checksum` verifies the chunk. Requires an 'upload' type token; only the key that created the upload can access it.
+//	@Tags			Resumable Uploads
+//	@Accept			application/offset+octet-stream
+//	@Param			id				path	string	true	"Upload ID"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version"	Enums(1.0.0)
+//	@Param			Upload-Offset	header	integer	true	"Offset the chunk starts at"
+//	@Param			Upload-Checksum	header	string	false	"Chunk checksum: '<sha1|sha256|md5> <base64 digest>'"
+//	@Security		ApiKeyAuth
+//	@Success		204
+//	@Header			204	{integer}	Upload-Offset	"New offset"
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		409	{object}	object{error=string}
+//	@Failure		413	{object}	object{error=string}
+//	@Failure		415	{object}	object{error=string}
+//	@Failure		423	{object}	object{error=string}
+//	@Failure		460	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Failure		507	{object}	object{error=string}
+//	@Router			/uploads/{id} [patch]
+//
+// PatchTusUpload 实现 tus PATCH 请求及 checksum 扩展
+// PatchTusUpload implements the tus PATCH request and the checksum extension
+func (s *Server) PatchTusUpload(c *gin.Context) {
+	// 1. 验证 Token 和请求头
+	// 1. Validate Token and request headers
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeUpload) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	if c.ContentType() != tus.OffsetContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type must be " + tus.OffsetContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or missing Upload-Offset"})
+		return
+	}
+
+	var checksum hash.Hash
+	var expected []byte
+	if header := c.GetHeader("Upload-Checksum"); header != "" {
+		checksum, expected, err = tus.ParseChecksum(header)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	// 2. 获取上传锁，防止并发写入
+	// 2. Take the upload lock to prevent concurrent writes
+	id := c.Param("id")
+	unlock, ok := s.Tus.TryLock(id)
+	if !ok {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload is locked by another request"})
+		return
+	}
+	defer unlock()
+
+	upload, err := s.findTusUpload(c, id)
+	if errors.Is(err, tus.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load upload"})
+		return
+	}
+	if offset != upload.Offset {
+		c.JSON(http.StatusConflict, gin.H{"error": "Upload-Offset does not match the current offset"})
+		return
+	}
+
+	// 3. 写入分片
+	// 3. Write the chunk
+	remaining := upload.Length - upload.Offset
+	if c.Request.ContentLength > remaining {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Chunk exceeds Upload-Length"})
+		return
+	}
+
+	newOffset, err := s.Tus.WriteChunk(upload, c.Request.Body, remaining, checksum, expected)
+	c.Header("Upload-Offset", strconv.FormatInt(newOffset, 10))
+	if errors.Is(err, tus.ErrChecksumMismatch) {
+		c.JSON(tus.StatusChecksumMismatch, gin.H{"error": "Checksum mismatch"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to write chunk"})
+		return
+	}
+	upload.Offset = newOffset
+
+	// 4. 收到最后一个字节后，将文件移入目标区域
+	// 4. After the last byte arrives, move the file into its target area
+	if upload.Offset == upload.Length && !s.completeTusUpload(c, upload) {
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TerminateTusUpload godoc
+//
+//	@Summary		Terminate a resumable upload
+//	@Description	Discards an unfinished upload and its received data. Requires an 'upload' type token; only the key that created the upload can access it.
+//	@Tags			Resumable Uploads
+//	@Param			id				path	string	true	"Upload ID"
+//	@Param			Tus-Resumable	header	string	true	"Protocol version"	Enums(1.0.0)
+//	@Security		ApiKeyAuth
+//	@Success		204
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		404	{object}	object{error=string}
+//	@Failure		423	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/uploads/{id} [delete]
+//
+// TerminateTusUpload 实现 tus termination 扩展
+// TerminateTusUpload implements the tus termination extension
+func (s *Server) TerminateTusUpload(c *gin.Context) {
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeUpload) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id := c.Param("id")
+	audit.SetTarget(c, audit.UploadTarget(id))
+	unlock, ok := s.Tus.TryLock(id)
+	if !ok {
+		c.JSON(http.StatusLocked, gin.H{"error": "Upload is locked by another request"})
+		return
+	}
+	defer unlock()
+
+	if _, err := s.findTusUpload(c, id); errors.Is(err, tus.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load upload"})
+		return
+	}
+
+	err := s.Tus.Terminate(id)
+	if errors.Is(err, tus.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to terminate upload"})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// findTusUpload 读取当前 API Key 创建的上传；其他密钥创建的上传被视为不存在
+// findTusUpload loads an upload created by the current API key; uploads created by other keys count as missing
+func (s *Server) findTusUpload(c *gin.Context, id string) (*tus.Upload, error) {
+	upload, err := s.Tus.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey, _ := utility.CurrentAPIKey(c); apiKey.ID != upload.APIKeyID {
+		return nil, tus.ErrNotFound
+	}
+	return upload, nil
+}
+
+// completeTusUpload 将已完成的上传写入存储后端并清理临时数据；失败时已写出响应并返回 false。
+// 失败后上传会被保留，客户端可以用空的 PATCH 重试。
+// completeTusUpload stores a finished upload in the storage backend and removes the temporary data;
+// on failure it has already responded and returns false. The upload is kept so the client can retry with an empty PATCH.
+func (s *Server) completeTusUpload(c *gin.Context, upload *tus.Upload) bool {
+	audit.Mark(c, audit.ActionUpload, audit.FileTarget(string(upload.Visibility), upload.Filename))
+
+	// 只有创建上传的密钥能完成它；创建后其他上传可能已占用了配额，storeFile 会再次检查
+	// Only the key that created the upload may complete it; other uploads may have used up the quota since creation,
+	// which storeFile checks again
+	if apiKey, _ := utility.CurrentAPIKey(c); apiKey.ID != upload.APIKeyID {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Upload not found"})
+		return false
+	}
+
+	data, err := s.Tus.Open(upload.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open upload data"})
+		return false
+	}
+	defer data.Close()
+
+	opts := uploadOptions{Policy: ConflictPolicy(upload.OnConflict), RenameStyle: upload.RenameStyle}
+	stored, err := s.storeFile(c, upload.Visibility, upload.Filename, upload.Filename, opts, upload.Length, data)
+	if errors.Is(err, errFileExists) {
+		c.JSON(http.StatusConflict, gin.H{"error": "File already exists"})
+		return false
+	}
+	if isStorageLimit(err) {
+		respondStorageError(c, err)
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file: " + err.Error()})
+		return false
+	}
+
+	if err := s.Tus.Terminate(upload.ID); err != nil && !errors.Is(err, tus.ErrNotFound) {
+		// 文件已保存，清理失败只需记录 / The file is saved; a failed cleanup is only worth noting
+		c.Error(err)
+	}
+
+	audit.SetTarget(c, audit.FileTarget(stored.File.Visibility, stored.File.Name))
+	c.Header("X-GoFi-Download-Path", "/"+stored.File.Name)
+	return true
+}
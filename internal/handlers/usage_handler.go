@@ -0,0 +1,283 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+)
+
+// KeyUsage 表示一个 API Key 上传的文件占用的存储空间
+// KeyUsage represents the storage taken by the files an API key uploaded
+type KeyUsage struct {
+	KeyID      uint   `json:"key_id"`
+	Prefix     string `json:"prefix"`
+	Label      string `json:"label"`
+	UsedBytes  int64  `json:"used_bytes"`
+	FileCount  int64  `json:"file_count"`
+	QuotaBytes *int64 `json:"quota_bytes"` // 为空表示不限制 / Null means unlimited
+}
+
+// UsageResponse 表示全部存储用量和分页的 API Key 用量
+// UsageResponse represents the overall storage usage and a page of per-key usage
+type UsageResponse struct {
+	Page
+	UsedBytes  int64      `json:"used_bytes"`
+	FileCount  int64      `json:"file_count"`
+	QuotaBytes *int64     `json:"quota_bytes"` // 全局上限，为空表示不限制 / Global cap, null means unlimited
+	Items      []KeyUsage `json:"items"`
+}
+
+// GetUsage godoc
+//
+//	@Summary		Get storage usage
+//	@Description	Returns the bytes stored overall and per API key (counting the files each key uploaded) together with the quotas. Requires an `admin` scope key.
+//	@Tags			API Keys
+//	@Produce		json
+//	@Param			page		query	integer	false	"Page number, starting at 1"
+//	@Param			page_size	query	integer	false	"Items per page (default 50, max 500)"
+//	@Security		ApiKeyAuth
+//	@Success		200	{object}	UsageResponse
+//	@Failure		400	{object}	object{error=string}
+//	@Failure		401	{object}	object{error=string}
+//	@Failure		500	{object}	object{error=string}
+//	@Router			/api/usage [get]
+//
+// GetUsage 返回存储用量和配额
+// GetUsage returns the storage usage and quotas
+func (s *Server) GetUsage(c *gin.Context) {
+	// 1. 验证 Token
+	// 1. Validate Token
+	// 用量涵盖所有密钥和文件，因此只对 admin 密钥开放
+	// Usage covers every key and file, so it is only open to admin keys
+	if !utility.IsTokenValid(c, s.APIKeys, models.ScopeAdmin) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	page, err := parsePagination(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 2. 汇总全部文件和当前页 API Key 的用量
+	// 2. Sum up all files and the usage of the keys on this page
+	total, err := s.Files.Usage()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query storage usage"})
+		return
+	}
+
+	query, err := paginate(s.APIKeys.Query(), &page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count API keys"})
+		return
+	}
+	var apiKeys []models.ApiKey
+	if err := query.Order("id").Find(&apiKeys).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list API keys"})
+		return
+	}
+
+	ids := make([]uint, len(apiKeys))
+	for i, apiKey := range apiKeys {
+		ids[i] = apiKey.ID
+	}
+	usages, err := s.Files.UsageByKey(ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query storage usage"})
+		return
+	}
+	byKey := make(map[uint]repository.StorageUsage, len(usages))
+	for _, usage := range usages {
+		if usage.ApiKeyID != nil {
+			byKey[*usage.ApiKeyID] = usage
+		}
+	}
+
+	// 3. 返回用量和配额
+	// 3. Return the usage and quotas
+	response := UsageResponse{
+		Page:       page,
+		UsedBytes:  total.UsedBytes,
+		FileCount:  total.FileCount,
+		QuotaBytes: quotaOrNil(s.Config.StorageQuotaTotal),
+		Items:      make([]KeyUsage, len(apiKeys)),
+	}
+	for i, apiKey := range apiKeys {
+		usage := byKey[apiKey.ID]
+		response.Items[i] = KeyUsage{
+			KeyID:      apiKey.ID,
+			Prefix:     apiKey.Prefix,
+			Label:      apiKey.Label,
+			UsedBytes:  usage.UsedBytes,
+			FileCount:  usage.FileCount,
+			QuotaBytes: quotaOrNil(storageQuota(s.Config, apiKey)),
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// errStorageFull 表示上传会超出全局存储上限
+// errStorageFull reports that an upload would exceed the global storage cap
+var errStorageFull = errors.New("storage is full")
+
+// quotaExceededError 表示上传会超出当前 API Key 的存储配额
+// quotaExceededError reports that an upload would exceed the current API key's storage quota
+type quotaExceededError struct {
+	Used  int64
+	Quota int64
+}
+
+func (e *quotaExceededError) Error() string {
+	return fmt.Sprintf("storage quota exceeded: %d of %d bytes used", e.Used, e.Quota)
+}
+
+// storageReservations 记录已通过配额检查、但文件记录尚未保存的上传所占的空间，使并发上传不能同时通过检查
+// storageReservations tracks the space of uploads that passed the quota check but whose file record is not saved yet,
+// so concurrent uploads cannot pass the check together
+type storageReservations struct {
+	mu    sync.Mutex
+	total int64
+	byKey map[uint]int64
+}
+
+// checkStorageQuota 检查再存储 size 字节是否会超出全局上限（507）或当前 API Key 的配额（413）；超出或查询失败时写入响应。
+// replaced 是上传将覆盖的文件，其大小不计入用量。这只是读取内容前的提前检查，storeFile 在写入前会再次检查并预留空间
+// checkStorageQuota checks whether storing size more bytes would exceed the global cap (507) or the current API key's
+// quota (413); it writes the response when a limit would be exceeded or the lookup fails. replaced is the file the upload
+// would overwrite, whose size no longer counts. This is only an early check before the content is read; storeFile checks
+// again and reserves the space before writing
+func (s *Server) checkStorageQuota(c *gin.Context, size int64, replaced *models.File) bool {
+	s.reservations.mu.Lock()
+	err := s.storageAvailable(c, size, replaced)
+	s.reservations.mu.Unlock()
+	if err != nil {
+		respondStorageError(c, err)
+		return false
+	}
+	return true
+}
+
+// reserveStorage 检查配额并预留 size 字节，直到调用返回的 release；文件记录应在 release 之前保存，
+// 这样空间始终至少被计入一次
+// reserveStorage checks the quotas and reserves size bytes until the returned release is called; the file record
+// should be saved before release, so the space always counts at least once
+func (s *Server) reserveStorage(c *gin.Context, size int64, replaced *models.File) (release func(), err error) {
+	r := &s.reservations
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := s.storageAvailable(c, size, replaced); err != nil {
+		return nil, err
+	}
+
+	apiKey, hasKey := utility.CurrentAPIKey(c)
+	if r.byKey == nil {
+		r.byKey = make(map[uint]int64)
+	}
+	r.total += size
+	if hasKey {
+		r.byKey[apiKey.ID] += size
+	}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.total -= size
+		if hasKey {
+			if r.byKey[apiKey.ID] -= size; r.byKey[apiKey.ID] == 0 {
+				delete(r.byKey, apiKey.ID)
+			}
+		}
+	}, nil
+}
+
+// storageAvailable 计入已预留的空间并减去 replaced 的大小后，检查 size 字节能否存下；调用方需持有 s.reservations.mu
+// storageAvailable checks whether size more bytes fit, counting the reserved space and leaving out the size of replaced;
+// the caller must hold s.reservations.mu
+func (s *Server) storageAvailable(c *gin.Context, size int64, replaced *models.File) error {
+	if s.Config.StorageQuotaTotal > 0 {
+		used, err := s.Files.UsedBytes(nil)
+		if err != nil {
+			return err
+		}
+		if replaced != nil {
+			used -= replaced.Size
+		}
+		if used+s.reservations.total+size > s.Config.StorageQuotaTotal {
+			return errStorageFull
+		}
+	}
+
+	apiKey, ok := utility.CurrentAPIKey(c)
+	if !ok {
+		return nil
+	}
+	quota := storageQuota(s.Config, apiKey)
+	if quota <= 0 {
+		return nil
+	}
+	used, err := s.Files.UsedBytes(&apiKey.ID)
+	if err != nil {
+		return err
+	}
+	// 被替换的文件只有属于当前密钥时才计入了它的用量
+	// The replaced file only counted towards this key's usage if the key uploaded it
+	if replaced != nil && replaced.ApiKeyID != nil && *replaced.ApiKeyID == apiKey.ID {
+		used -= replaced.Size
+	}
+	used += s.reservations.byKey[apiKey.ID]
+	if used+size > quota {
+		return &quotaExceededError{Used: used, Quota: quota}
+	}
+	return nil
+}
+
+// isStorageLimit 判断 err 是否表示超出了存储上限或配额
+// isStorageLimit reports whether err means the storage cap or a quota would be exceeded
+func isStorageLimit(err error) bool {
+	var exceeded *quotaExceededError
+	return errors.Is(err, errStorageFull) || errors.As(err, &exceeded)
+}
+
+// respondStorageError 为超出存储上限（507）或配额（413）的错误写入响应，其他错误视为查询失败（500）
+// respondStorageError responds to an exceeded storage cap (507) or quota (413); any other error counts as a failed lookup (500)
+func respondStorageError(c *gin.Context, err error) {
+	var exceeded *quotaExceededError
+	switch {
+	case errors.Is(err, errStorageFull):
+		c.JSON(http.StatusInsufficientStorage, gin.H{"error": "Storage is full"})
+	case errors.As(err, &exceeded):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{
+			"error":       "Storage quota exceeded",
+			"used_bytes":  exceeded.Used,
+			"quota_bytes": exceeded.Quota,
+		})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query storage usage"})
+	}
+}
+
+// storageQuota 返回 API Key 实际生效的存储配额，0 表示不限制
+// storageQuota returns the storage quota in effect for an API key, 0 means unlimited
+func storageQuota(config *config.Config, apiKey models.ApiKey) int64 {
+	if apiKey.StorageQuota != nil {
+		return *apiKey.StorageQuota
+	}
+	return config.StorageQuotaPerKey
+}
+
+// quotaOrNil 将 0（不限制）转换为 nil，便于在 JSON 中表示为 null
+// quotaOrNil turns 0 (unlimited) into nil so it shows up as null in JSON
+func quotaOrNil(quota int64) *int64 {
+	if quota <= 0 {
+		return nil
+	}
+	return &quota
+}
@@ -1,23 +1,151 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"slices"
+	"strings"
+	"time"
 
-// ApiKeyType 定义了 API 密钥的类型
-// ApiKeyType defines the type of the API key
-type ApiKeyType string
+	"gorm.io/gorm"
+)
+
+// APIKeyMarker 是新生成密钥的固定开头，便于在日志和代码中识别泄露的密钥
+// APIKeyMarker starts every generated key so leaked keys are easy to spot in logs and code
+const APIKeyMarker = "gofi_"
+
+// Scope 定义了 API 密钥可执行的一类操作
+// Scope defines a kind of operation an API key may perform
+type Scope string
 
 const (
-	ApiKeyTypeUpload   ApiKeyType = "upload"
-	ApiKeyTypeDownload ApiKeyType = "download"
-	ApiKeyTypeShorten  ApiKeyType = "shorten"
-	ApiKeyTypeAPI      ApiKeyType = "api"
+	ScopeUpload   Scope = "upload"
+	ScopeDownload Scope = "download"
+	ScopeShorten  Scope = "shorten"
+	ScopeAPI      Scope = "api" // 管理 API Key / Manage API keys
+	ScopeList     Scope = "list"
+	ScopeDelete   Scope = "delete"
+	ScopeAdmin    Scope = "admin" // 包含所有权限 / Implies every scope
 )
 
-// ApiKey 代表访问 API 的令牌
-// ApiKey represents a token for accessing the API
+// AllScopes 列出所有受支持的权限范围
+// AllScopes lists every supported scope
+var AllScopes = []Scope{ScopeUpload, ScopeDownload, ScopeShorten, ScopeAPI, ScopeList, ScopeDelete, ScopeAdmin}
+
+// ParseScope 将字符串解析为 Scope
+// ParseScope converts a string into a Scope
+func ParseScope(input string) (Scope, bool) {
+	scope := Scope(strings.ToLower(strings.TrimSpace(input)))
+	if slices.Contains(AllScopes, scope) {
+		return scope, true
+	}
+	return "", false
+}
+
+// ApiKey 代表访问 API 的令牌；只保存密钥的前缀和 SHA-256 哈希，不保存密钥本身
+// ApiKey represents a token for accessing the API; only a prefix and the SHA-256 hash of the key are stored, never the key itself
 type ApiKey struct {
 	gorm.Model
-	Key       string     `gorm:"type:varchar(255);uniqueIndex;not null"` // 密钥 / Key
-	Type      ApiKeyType `gorm:"type:varchar(50);not null"`              // 密钥类型 / Key Type
-	IsEnabled bool       `gorm:"default:true"`                           // 是否启用 / Is Enabled
+	Prefix     string     `gorm:"type:varchar(16);index;not null"`       // 用于识别密钥的前缀 / Prefix identifying the key
+	KeyHash    string     `gorm:"type:varchar(64);uniqueIndex;not null"` // 密钥的 SHA-256 哈希 / SHA-256 hash of the key
+	Scopes     string     `gorm:"type:varchar(255);not null"`            // 以逗号分隔的权限范围 / Comma-separated scopes
+	Label      string     `gorm:"type:varchar(100);not null;default:''"` // 用途或持有人 / Purpose or owner
+	IsEnabled  bool       `gorm:"default:true"`                          // 是否启用 / Is Enabled
+	ExpiresAt  *time.Time `gorm:"index"`                                 // 过期时间，为空表示永不过期 / Expiry time, never expires when null
+	LastUsedAt *time.Time // 最近一次成功验证的时间（按分钟节流） / Time of the last successful authentication (throttled to minutes)
+	LastUsedIP string     `gorm:"type:varchar(45);not null;default:''"` // 最近一次使用的客户端 IP / Client IP of the last use
+
+	// 路径限制：为空表示不限制 / Path restriction: empty means unrestricted
+	Visibility  string `gorm:"type:varchar(20);not null;default:''"`  // 只允许 public 或 private 区域 / Only the public or private area
+	NamePattern string `gorm:"type:varchar(255);not null;default:''"` // 文件名 glob，如 "team-a-*" / Filename glob, e.g. "team-a-*"
+
+	// 限流覆盖：为空时使用配置中的默认值，0 表示不限制 / Rate limit overrides: null uses the configured default, 0 means unlimited
+	RateLimitRequests  *float64 // 每秒请求数 / Requests per second
+	RateLimitBurst     *int     // 突发请求数 / Request burst
+	RateLimitBandwidth *int64   // 每秒字节数 / Bytes per second
+
+	// 存储配额（字节）：为空时使用配置中的默认值，0 表示不限制 / Storage quota in bytes: null uses the configured default, 0 means unlimited
+	StorageQuota *int64
+}
+
+// IsRestricted 判断密钥是否带有路径限制
+// IsRestricted reports whether the key carries a path restriction
+func (k ApiKey) IsRestricted() bool {
+	return k.Visibility != "" || k.NamePattern != ""
+}
+
+// AllowsFile 判断路径限制是否允许访问 visibility 区域中名为 name 的文件
+// AllowsFile reports whether the path restriction allows access to the file called name in the visibility area
+func (k ApiKey) AllowsFile(visibility, name string) bool {
+	if k.Visibility != "" && k.Visibility != visibility {
+		return false
+	}
+	if k.NamePattern == "" {
+		return true
+	}
+	matched, err := path.Match(k.NamePattern, name)
+	return err == nil && matched
+}
+
+// Confines 判断 other 的路径限制是否在 k 的限制范围内；不受限制的 k 包含所有密钥
+// Confines reports whether other's path restriction lies within k's; an unrestricted k confines every key
+func (k ApiKey) Confines(other ApiKey) bool {
+	return (k.Visibility == "" || other.Visibility == k.Visibility) &&
+		(k.NamePattern == "" || other.NamePattern == k.NamePattern)
+}
+
+// IsExpired 判断密钥在 now 时是否已过期
+// IsExpired reports whether the key has expired at now
+func (k ApiKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// ScopeList 返回密钥的权限范围列表
+// ScopeList returns the key's scopes as a list
+func (k ApiKey) ScopeList() []Scope {
+	var scopes []Scope
+	for _, scope := range strings.Split(k.Scopes, ",") {
+		if scope != "" {
+			scopes = append(scopes, Scope(scope))
+		}
+	}
+	return scopes
+}
+
+// SetScopes 去重并按固定顺序保存权限范围
+// SetScopes stores the scopes deduplicated and in a fixed order
+func (k *ApiKey) SetScopes(scopes []Scope) {
+	var names []string
+	for _, scope := range AllScopes {
+		if slices.Contains(scopes, scope) {
+			names = append(names, string(scope))
+		}
+	}
+	k.Scopes = strings.Join(names, ",")
+}
+
+// HasScope 判断密钥是否拥有某个权限范围；admin 包含所有权限
+// HasScope reports whether the key has a scope; admin implies every scope
+func (k ApiKey) HasScope(scope Scope) bool {
+	scopes := k.ScopeList()
+	return slices.Contains(scopes, scope) || slices.Contains(scopes, ScopeAdmin)
+}
+
+// APIKeyPrefix 返回密钥的识别前缀；旧格式的密钥最多取前 8 个字符，且不超过一半长度
+// APIKeyPrefix returns the identifying prefix of a key; keys in the old format use at most 8 characters and never more than half the key
+func APIKeyPrefix(key string) string {
+	if rest, ok := strings.CutPrefix(key, APIKeyMarker); ok {
+		if prefix, _, ok := strings.Cut(rest, "_"); ok {
+			return prefix
+		}
+	}
+	return key[:min(8, len(key)/2)]
+}
+
+// HashAPIKey 返回密钥的 SHA-256 十六进制哈希
+// HashAPIKey returns the hex SHA-256 hash of a key
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
 }
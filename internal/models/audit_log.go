@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrAuditLogImmutable 表示试图修改或删除审计记录
+// ErrAuditLogImmutable reports an attempt to change or delete an audit entry
+var ErrAuditLogImmutable = errors.New("audit log entries are append-only")
+
+// AuditLog 对应于数据库中的 audit_logs 表，记录一次上传、私有文件下载或管理操作；只追加，不修改
+// AuditLog corresponds to the audit_logs table in the database and records one upload, private download
+// or management operation; entries are only ever appended
+type AuditLog struct {
+	ID         uint      `gorm:"primaryKey"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index"`
+	ActorKeyID *uint     `gorm:"index"`                           // 发起请求的 API Key，为空表示未携带有效密钥 / API key behind the request, null when none was presented
+	Action     string    `gorm:"type:varchar(50);not null;index"` // 如 file.upload / E.g. file.upload
+	Target     string    `gorm:"type:varchar(1024);not null"`     // 如 private/report.pdf / E.g. private/report.pdf
+	ClientIP   string    `gorm:"type:varchar(45)"`                // 客户端 IP / Client IP
+	UserAgent  string    `gorm:"type:varchar(512)"`               // 客户端 User-Agent / Client User-Agent
+	Outcome    string    `gorm:"type:varchar(20);not null;index"` // success、denied 或 failure / success, denied or failure
+	Status     int       `gorm:"not null"`                        // 响应状态码 / Response status code
+}
+
+// BeforeUpdate 拒绝修改审计记录
+// BeforeUpdate refuses to change an audit entry
+func (AuditLog) BeforeUpdate(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
+
+// BeforeDelete 拒绝删除审计记录
+// BeforeDelete refuses to delete an audit entry
+func (AuditLog) BeforeDelete(*gorm.DB) error {
+	return ErrAuditLogImmutable
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// File 对应于数据库中的 files 表，是已存储文件的权威记录
+// File corresponds to the files table in the database and is the source of truth for stored files
+type File struct {
+	ID           uint      `gorm:"primaryKey"`
+	Name         string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_files_visibility_name,priority:2"` // 存储名称 / Stored name
+	Visibility   string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_files_visibility_name,priority:1"`  // public 或 private / public or private
+	OriginalName string    `gorm:"type:varchar(255);not null"`                                                  // 上传时的文件名 / Filename as uploaded
+	Size         int64     `gorm:"not null"`
+	SHA256       string    `gorm:"column:sha256;type:varchar(64);not null"`
+	MimeType     string    `gorm:"type:varchar(255);not null"`
+	ApiKeyID     *uint     `gorm:"index"` // 上传所用的 API Key / API key used for the upload
+	CreatedAt    time.Time `gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime"`
+}
+
+// IsPrivate 报告文件是否位于 private 区域
+// IsPrivate reports whether the file lives in the private area
+func (f File) IsPrivate() bool {
+	return f.Visibility == "private"
+}
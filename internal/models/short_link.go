@@ -5,10 +5,49 @@ import "time"
 // ShortLink 对应于数据库中的 short_links 表
 // ShortLink corresponds to the short_links table in the database
 type ShortLink struct {
-	ID               uint      `gorm:"primaryKey"`
-	ShortCode        string    `gorm:"type:varchar(20);uniqueIndex;not null"`
-	OriginalFilename string    `gorm:"type:varchar(255);not null"`
-	IsPrivate        bool      `gorm:"not null;default:true"`
-	IsEnabled        bool      `gorm:"not null;default:true"` // 控制此短链接是否启用 / Controls if this short link is enabled
-	CreatedAt        time.Time `gorm:"autoCreateTime"`
+	ID               uint       `gorm:"primaryKey"`
+	ShortCode        string     `gorm:"type:varchar(64);uniqueIndex;not null"`
+	FileID           *uint      `gorm:"index"` // 指向的文件 / The file this link points to
+	File             *File      `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL"`
+	OriginalFilename string     `gorm:"type:varchar(255);not null"` // 创建或重新指向时的文件名，仅供展示 / Filename when the link was created or repointed, for display only
+	IsPrivate        bool       `gorm:"not null;default:true"`
+	Visibility       string     `gorm:"type:varchar(20);not null;default:''"` // 覆盖文件的可见性，为空表示沿用文件 / Overrides the file's visibility, empty means inherit
+	IsEnabled        bool       `gorm:"not null;default:true"`                // 控制此短链接是否启用 / Controls if this short link is enabled
+	ExpiresAt        *time.Time // 过期时间，为空表示永不过期 / Expiry time, nil means never
+	MaxDownloads     *int64     // 最大下载次数，为空表示不限 / Download limit, nil means unlimited
+	DownloadCount    int64      `gorm:"not null;default:0"`     // 已下载次数 / Number of downloads served
+	PasswordHash     string     `gorm:"type:varchar(100)"`      // bcrypt 哈希，为空表示无密码 / bcrypt hash, empty means no password
+	CreatedByKeyID   *uint      `gorm:"index"`                  // 创建所用的 API Key / API key used to create the link
+	LegacyUnlinked   bool       `gorm:"not null;default:false"` // 旧短链接尚未按文件名关联到文件 / Legacy link not yet linked to its file by filename
+	CreatedAt        time.Time  `gorm:"autoCreateTime"`
+}
+
+// IsExpired 判断短链接在 now 时是否已过期
+// IsExpired reports whether the short link has expired at now
+func (s ShortLink) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
+// IsExhausted 判断短链接是否已用完下载次数
+// IsExhausted reports whether the short link has used up its downloads
+func (s ShortLink) IsExhausted() bool {
+	return s.MaxDownloads != nil && s.DownloadCount >= *s.MaxDownloads
+}
+
+// HasPassword 判断短链接是否受密码保护
+// HasPassword reports whether the short link is password protected
+func (s ShortLink) HasPassword() bool {
+	return s.PasswordHash != ""
+}
+
+// EffectiveVisibility 返回短链接实际生效的可见性：优先使用覆盖值，否则沿用所指文件
+// EffectiveVisibility returns the visibility that applies to the short link: the override if set, otherwise the file's
+func (s ShortLink) EffectiveVisibility() string {
+	if s.Visibility != "" {
+		return s.Visibility
+	}
+	if s.File != nil {
+		return s.File.Visibility
+	}
+	return "private"
 }
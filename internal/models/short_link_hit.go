@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// ShortLinkHit 对应于数据库中的 short_link_hits 表，记录一次短链接访问
+// ShortLinkHit corresponds to the short_link_hits table in the database and records one short link visit
+type ShortLinkHit struct {
+	ID          uint       `gorm:"primaryKey"`
+	ShortLinkID uint       `gorm:"not null;index:idx_short_link_hits_link_time,priority:1"`
+	ShortLink   *ShortLink `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	ClientIP    string     `gorm:"type:varchar(45)"`   // 可能已截断或为空 / May be truncated or empty
+	UserAgent   string     `gorm:"type:varchar(512)"`  // 客户端 User-Agent / Client User-Agent
+	Referrer    string     `gorm:"type:varchar(1024)"` // 来源页面 / Referring page
+	Status      int        `gorm:"not null"`           // 响应状态码 / Response status code
+	BytesServed int64      `gorm:"not null;default:0"` // 成功响应中发送的字节数 / Bytes sent in a successful response
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index:idx_short_link_hits_link_time,priority:2"`
+}
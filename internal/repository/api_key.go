@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"gorm.io/gorm"
+)
+
+// ApiKeyRepo 负责 api_keys 表的读写
+// ApiKeyRepo reads and writes the api_keys table
+type ApiKeyRepo struct {
+	db *gorm.DB
+}
+
+// NewApiKeyRepo 创建使用 db 的 ApiKeyRepo
+// NewApiKeyRepo creates an ApiKeyRepo using db
+func NewApiKeyRepo(db *gorm.DB) *ApiKeyRepo {
+	return &ApiKeyRepo{db: db}
+}
+
+// Query 返回 api_keys 表上的查询，供列表接口按请求参数组合过滤条件
+// Query returns a query on the api_keys table for listings to combine filters from request parameters
+func (r *ApiKeyRepo) Query() *gorm.DB {
+	return r.db.Model(&models.ApiKey{})
+}
+
+// FindByHash 按密钥哈希查找 API Key
+// FindByHash finds an API key by the hash of the key
+func (r *ApiKeyRepo) FindByHash(keyHash string) (models.ApiKey, error) {
+	var apiKey models.ApiKey
+	err := r.db.Where("key_hash = ?", keyHash).First(&apiKey).Error
+	return apiKey, err
+}
+
+// Create 创建 API Key
+// Create creates an API key
+func (r *ApiKeyRepo) Create(apiKey *models.ApiKey) error {
+	return r.db.Create(apiKey).Error
+}
+
+// SetEnabled 启用或禁用 API Key
+// SetEnabled enables or disables an API key
+func (r *ApiKeyRepo) SetEnabled(apiKey *models.ApiKey, enabled bool) error {
+	return r.db.Model(apiKey).Update("is_enabled", enabled).Error
+}
+
+// Touch 记录密钥在 now 时被 clientIP 使用；只有上次记录早于 staleBefore 时才写入，
+// 因此并发请求中只有一个会真正写入
+// Touch records that clientIP used the key at now; it only writes when the last record is older than staleBefore,
+// so only one of several concurrent requests actually writes
+func (r *ApiKeyRepo) Touch(id uint, clientIP string, now, staleBefore time.Time) error {
+	return r.Query().
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, staleBefore.UTC()).
+		UpdateColumns(map[string]any{"last_used_at": now.UTC(), "last_used_ip": clientIP}).Error
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepo 负责 audit_logs 表的读写；审计记录只追加，不修改
+// AuditLogRepo reads and writes the audit_logs table; audit entries are only ever appended
+type AuditLogRepo struct {
+	db *gorm.DB
+}
+
+// NewAuditLogRepo 创建使用 db 的 AuditLogRepo
+// NewAuditLogRepo creates an AuditLogRepo using db
+func NewAuditLogRepo(db *gorm.DB) *AuditLogRepo {
+	return &AuditLogRepo{db: db}
+}
+
+// Query 返回 audit_logs 表上的查询，供查询接口按请求参数组合过滤条件
+// Query returns a query on the audit_logs table for the query endpoint to combine filters from request parameters
+func (r *AuditLogRepo) Query() *gorm.DB {
+	return r.db.Model(&models.AuditLog{})
+}
+
+// Create 追加一条审计记录
+// Create appends an audit entry
+func (r *AuditLogRepo) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
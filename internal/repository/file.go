@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"gorm.io/gorm"
+)
+
+// StorageUsage 是按上传者汇总的文件大小，ApiKeyID 为空表示汇总全部文件
+// StorageUsage is the file size summed up per uploader; a nil ApiKeyID means all files
+type StorageUsage struct {
+	ApiKeyID  *uint
+	UsedBytes int64
+	FileCount int64
+}
+
+// FileRepo 负责 files 表的读写
+// FileRepo reads and writes the files table
+type FileRepo struct {
+	db *gorm.DB
+}
+
+// NewFileRepo 创建使用 db 的 FileRepo
+// NewFileRepo creates a FileRepo using db
+func NewFileRepo(db *gorm.DB) *FileRepo {
+	return &FileRepo{db: db}
+}
+
+// Query 返回 files 表上的查询，供列表接口按请求参数组合过滤条件
+// Query returns a query on the files table for listings to combine filters from request parameters
+func (r *FileRepo) Query() *gorm.DB {
+	return r.db.Model(&models.File{})
+}
+
+// Find 按名称和可见性查找文件记录
+// Find looks up a file record by name and visibility
+func (r *FileRepo) Find(name, visibility string) (models.File, error) {
+	var file models.File
+	err := r.db.Where("name = ? AND visibility = ?", name, visibility).First(&file).Error
+	return file, err
+}
+
+// Names 返回某个区域中所有文件记录的名称
+// Names returns the names of every file record in an area
+func (r *FileRepo) Names(visibility string) ([]string, error) {
+	var names []string
+	err := r.Query().Where("visibility = ?", visibility).Pluck("name", &names).Error
+	return names, err
+}
+
+// Create 创建文件记录
+// Create creates a file record
+func (r *FileRepo) Create(file *models.File) error {
+	return r.db.Create(file).Error
+}
+
+// Save 按 file 的名称和可见性创建记录，已存在时更新为 attrs；更新时保留原记录的 ID
+// Save creates the record named by file's name and visibility, or updates it with attrs when it exists; updates keep the existing ID
+func (r *FileRepo) Save(file *models.File, attrs map[string]any) error {
+	return r.db.Where(models.File{Name: file.Name, Visibility: file.Visibility}).Assign(attrs).FirstOrCreate(file).Error
+}
+
+// Rename 修改文件记录的名称
+// Rename changes the name of a file record
+func (r *FileRepo) Rename(file *models.File, name string) error {
+	return r.db.Model(file).Update("name", name).Error
+}
+
+// Delete 在一个事务中删除文件记录，并删除或禁用指向它的短链接；removeObject 在同一事务中删除存储对象，返回错误时整体回滚。
+// 返回受影响的短链接数量。
+// Delete removes a file record in one transaction, deleting or disabling the short links pointing at it; removeObject deletes
+// the stored object within the same transaction, and an error from it rolls everything back. It returns the number of short links affected.
+func (r *FileRepo) Delete(file models.File, deleteLinks bool, removeObject func() error) (int64, error) {
+	var affected int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// 尚未关联到记录的旧短链接按文件名匹配
+		// Legacy short links not yet linked to a record are matched by filename
+		links := tx.Model(&models.ShortLink{}).Where(
+			"file_id = ? OR (legacy_unlinked = ? AND file_id IS NULL AND original_filename = ? AND is_private = ?)",
+			file.ID, true, file.Name, file.IsPrivate(),
+		)
+
+		var result *gorm.DB
+		if deleteLinks {
+			result = links.Delete(&models.ShortLink{})
+		} else {
+			result = links.Updates(map[string]any{"is_enabled": false, "file_id": nil, "legacy_unlinked": false})
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		affected = result.RowsAffected
+
+		if err := tx.Delete(&file).Error; err != nil {
+			return err
+		}
+		return removeObject()
+	})
+	return affected, err
+}
+
+// UsedBytes 返回 API Key 上传的文件的总大小；apiKeyID 为空时返回全部文件的总大小
+// UsedBytes returns the total size of the files an API key uploaded; with a nil apiKeyID it covers all files
+func (r *FileRepo) UsedBytes(apiKeyID *uint) (int64, error) {
+	query := r.Query().Select("COALESCE(SUM(size), 0)")
+	if apiKeyID != nil {
+		query = query.Where("api_key_id = ?", *apiKeyID)
+	}
+	var used int64
+	err := query.Scan(&used).Error
+	return used, err
+}
+
+// Usage 返回全部文件的总大小和数量
+// Usage returns the total size and number of all files
+func (r *FileRepo) Usage() (StorageUsage, error) {
+	var usage StorageUsage
+	err := r.Query().Select("COALESCE(SUM(size), 0) AS used_bytes, COUNT(*) AS file_count").Scan(&usage).Error
+	return usage, err
+}
+
+// UsageByKey 返回每个 API Key 上传的文件的总大小和数量；没有文件的密钥不会出现在结果中
+// UsageByKey returns the total size and number of the files each API key uploaded; keys without files are left out
+func (r *FileRepo) UsageByKey(apiKeyIDs []uint) ([]StorageUsage, error) {
+	var usages []StorageUsage
+	err := r.Query().
+		Select("api_key_id, COALESCE(SUM(size), 0) AS used_bytes, COUNT(*) AS file_count").
+		Where("api_key_id IN ?", apiKeyIDs).
+		Group("api_key_id").
+		Scan(&usages).Error
+	return usages, err
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"gorm.io/gorm"
+)
+
+// ShortLinkRepo 负责 short_links 和 short_link_hits 表的读写
+// ShortLinkRepo reads and writes the short_links and short_link_hits tables
+type ShortLinkRepo struct {
+	db *gorm.DB
+}
+
+// NewShortLinkRepo 创建使用 db 的 ShortLinkRepo
+// NewShortLinkRepo creates a ShortLinkRepo using db
+func NewShortLinkRepo(db *gorm.DB) *ShortLinkRepo {
+	return &ShortLinkRepo{db: db}
+}
+
+// Query 返回 short_links 表上的查询，供列表接口按请求参数组合过滤条件
+// Query returns a query on the short_links table for listings to combine filters from request parameters
+func (r *ShortLinkRepo) Query() *gorm.DB {
+	return r.db.Model(&models.ShortLink{})
+}
+
+// FindByCode 按短代码查找短链接及其文件
+// FindByCode finds a short link and its file by short code
+func (r *ShortLinkRepo) FindByCode(code string) (models.ShortLink, error) {
+	var shortLink models.ShortLink
+	err := r.db.Preload("File").Where("short_code = ?", code).First(&shortLink).Error
+	return shortLink, err
+}
+
+// CodeExists 判断短代码是否已被占用
+// CodeExists reports whether a short code is already taken
+func (r *ShortLinkRepo) CodeExists(code string) (bool, error) {
+	var count int64
+	err := r.Query().Where("short_code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+// ListByFileIDs 返回指向这些文件的短链接
+// ListByFileIDs returns the short links pointing at these files
+func (r *ShortLinkRepo) ListByFileIDs(fileIDs []uint) ([]models.ShortLink, error) {
+	var shortLinks []models.ShortLink
+	err := r.db.Where("file_id IN ?", fileIDs).Order("id").Find(&shortLinks).Error
+	return shortLinks, err
+}
+
+// Create 创建短链接
+// Create creates a short link
+func (r *ShortLinkRepo) Create(shortLink *models.ShortLink) error {
+	return r.db.Create(shortLink).Error
+}
+
+// Update 修改短链接的若干列
+// Update changes some columns of a short link
+func (r *ShortLinkRepo) Update(shortLink *models.ShortLink, updates map[string]any) error {
+	return r.db.Model(shortLink).Updates(updates).Error
+}
+
+// SetEnabled 启用或禁用短链接
+// SetEnabled enables or disables a short link
+func (r *ShortLinkRepo) SetEnabled(shortLink *models.ShortLink, enabled bool) error {
+	return r.db.Model(shortLink).Update("is_enabled", enabled).Error
+}
+
+// ClaimDownload 原子地占用一次下载次数；链接已过期或已用完次数时返回 false
+// ClaimDownload atomically claims one download; it returns false when the link has expired or used up its downloads
+func (r *ShortLinkRepo) ClaimDownload(id uint, now time.Time) (bool, error) {
+	result := r.Query().
+		Where("id = ? AND (max_downloads IS NULL OR download_count < max_downloads)", id).
+		Where("expires_at IS NULL OR expires_at > ?", now.UTC()).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+	return result.RowsAffected > 0, result.Error
+}
+
+// ReleaseDownload 归还一次已占用但未能提供下载的次数
+// ReleaseDownload gives back a claimed download that could not be served
+func (r *ShortLinkRepo) ReleaseDownload(id uint) error {
+	return r.Query().
+		Where("id = ? AND download_count > 0", id).
+		UpdateColumn("download_count", gorm.Expr("download_count - 1")).Error
+}
+
+// LinkLegacy 将迁移时标记的、只记录了文件名和隐私状态的旧短链接关联到对应的文件，返回关联的数量；
+// 之后清除所有标记，找不到文件的旧短链接不会在以后被关联到新上传的同名文件
+// LinkLegacy links the legacy short links marked by migration, which only know the filename and privacy flag, to their
+// files and returns how many it linked; it then clears every mark, so legacy links whose file is gone are never linked
+// to a later upload with the same name
+func (r *ShortLinkRepo) LinkLegacy() (int64, error) {
+	const matchingFile = `SELECT files.id FROM files
+		WHERE files.name = short_links.original_filename
+		AND files.visibility = CASE WHEN short_links.is_private THEN 'private' ELSE 'public' END`
+	var linked int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE short_links SET file_id = (`+matchingFile+`)
+			WHERE legacy_unlinked = ? AND file_id IS NULL AND EXISTS (`+matchingFile+`)`, true)
+		if result.Error != nil {
+			return result.Error
+		}
+		linked = result.RowsAffected
+		return tx.Model(&models.ShortLink{}).Where("legacy_unlinked = ?", true).Update("legacy_unlinked", false).Error
+	})
+	return linked, err
+}
+
+// CreateHits 批量写入访问记录
+// CreateHits writes visit records in batches
+func (r *ShortLinkRepo) CreateHits(hits []models.ShortLinkHit, batchSize int) error {
+	return r.db.Omit("ShortLink").CreateInBatches(hits, batchSize).Error
+}
+
+// Hits 按时间顺序返回短链接在 [from, to) 内的访问记录
+// Hits returns the visits of a short link within [from, to) in time order
+func (r *ShortLinkRepo) Hits(shortLinkID uint, from, to time.Time) ([]models.ShortLinkHit, error) {
+	var hits []models.ShortLinkHit
+	err := r.db.Select("client_ip", "status", "bytes_served", "created_at").
+		Where("short_link_id = ? AND created_at >= ? AND created_at < ?", shortLinkID, from.UTC(), to.UTC()).
+		Order("created_at").
+		Find(&hits).Error
+	return hits, err
+}
@@ -0,0 +1,200 @@
+package router
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+func TestAPIKeyLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	api := ts.seedKey(models.ScopeAPI)
+
+	// 1. 创建密钥，完整密钥只在创建时返回
+	// 1. Create a key; the full key is only returned on creation
+	rec := ts.sendJSON(http.MethodPost, "/api-keys", api, handlers.CreateAPIKeyRequest{Scopes: []string{"upload"}, Label: "ci"})
+	expectStatus(t, rec, http.StatusCreated)
+	var created handlers.ApiKeyResponse
+	decodeJSON(t, rec, &created)
+	if created.Key == "" || created.Label != "ci" || !created.IsEnabled {
+		t.Fatalf("created key = %+v", created)
+	}
+	ts.mustUpload(created.Key, storage.VisibilityPublic, "a.txt", "a")
+
+	// 2. 列表中不包含密钥本身
+	// 2. Listings never include the key itself
+	var list handlers.ApiKeyListResponse
+	decodeJSON(t, ts.get("/api-keys?label=ci", api), &list)
+	if list.Total != 1 || len(list.Items) != 1 || list.Items[0].ID != created.ID || list.Items[0].Key != "" {
+		t.Fatalf("listing = %+v", list)
+	}
+
+	// 3. 禁用后密钥失效，重新启用后恢复；可以按 ID 或前缀指定，前缀带上 gofi_ 以免纯数字前缀被当作 ID
+	// 3. A disabled key stops working and works again once re-enabled; it can be named by ID or prefix,
+	//    the prefix with gofi_ so an all-digit prefix is not taken for an ID
+	id := strconv.FormatUint(uint64(created.ID), 10)
+	expectStatus(t, ts.do(http.MethodDelete, "/api-keys/"+id, api, nil, nil), http.StatusOK)
+	expectStatus(t, ts.upload(created.Key, storage.VisibilityPublic, "b.txt", "b"), http.StatusUnauthorized)
+
+	expectStatus(t, ts.do(http.MethodPost, "/api-keys/"+models.APIKeyMarker+created.Prefix+"/enable", api, nil, nil), http.StatusOK)
+	ts.mustUpload(created.Key, storage.VisibilityPublic, "b.txt", "b")
+}
+
+func TestAPIKeyEndpointsRequireAPIScope(t *testing.T) {
+	ts := newTestServer(t)
+	upload := ts.seedKey(models.ScopeUpload, models.ScopeDownload, models.ScopeShorten)
+	target := ts.seedKey(models.ScopeUpload)
+
+	expectStatus(t, ts.get("/api-keys", ""), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/api-keys", upload), http.StatusUnauthorized)
+	rec := ts.sendJSON(http.MethodPost, "/api-keys", upload, handlers.CreateAPIKeyRequest{Scopes: []string{"upload"}})
+	expectStatus(t, rec, http.StatusUnauthorized)
+	expectStatus(t, ts.do(http.MethodDelete, "/api-keys/1", upload, nil, nil), http.StatusUnauthorized)
+
+	// 没有权限的调用不会影响目标密钥
+	// Unauthorized calls leave the target key alone
+	ts.mustUpload(target, storage.VisibilityPublic, "a.txt", "a")
+}
+
+func TestAPIKeyCreateValidation(t *testing.T) {
+	ts := newTestServer(t)
+	api := ts.seedKey(models.ScopeAPI)
+
+	for name, tc := range map[string]struct {
+		req  handlers.CreateAPIKeyRequest
+		want int
+	}{
+		"no scope":      {handlers.CreateAPIKeyRequest{}, http.StatusBadRequest},
+		"unknown scope": {handlers.CreateAPIKeyRequest{Scopes: []string{"root"}}, http.StatusBadRequest},
+		"admin scope":   {handlers.CreateAPIKeyRequest{Scopes: []string{"admin"}}, http.StatusForbidden},
+		"storage quota": {handlers.CreateAPIKeyRequest{Scopes: []string{"upload"}, StorageQuota: new(int64)}, http.StatusForbidden},
+		"legacy type":   {handlers.CreateAPIKeyRequest{Type: "download"}, http.StatusCreated},
+	} {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, ts.sendJSON(http.MethodPost, "/api-keys", api, tc.req), tc.want)
+		})
+	}
+
+	// admin 密钥可以签发 admin 密钥
+	// Admin keys can issue admin keys
+	rec := ts.sendJSON(http.MethodPost, "/api-keys", ts.seedKey(models.ScopeAdmin), handlers.CreateAPIKeyRequest{Scopes: []string{"admin"}})
+	expectStatus(t, rec, http.StatusCreated)
+}
+
+func TestRestrictedKeyCannotWidenRestriction(t *testing.T) {
+	ts := newTestServer(t)
+	restricted := ts.seedKeyWith(func(k *models.ApiKey) { k.NamePattern = "team-a-*" }, models.ScopeAPI)
+
+	rec := ts.sendJSON(http.MethodPost, "/api-keys", restricted, handlers.CreateAPIKeyRequest{Scopes: []string{"upload"}, NamePattern: "*"})
+	expectStatus(t, rec, http.StatusForbidden)
+
+	// 未指定限制时继承调用方的限制
+	// Without a restriction the caller's is inherited
+	rec = ts.sendJSON(http.MethodPost, "/api-keys", restricted, handlers.CreateAPIKeyRequest{Scopes: []string{"upload"}})
+	expectStatus(t, rec, http.StatusCreated)
+	var created handlers.ApiKeyResponse
+	decodeJSON(t, rec, &created)
+	if created.NamePattern != "team-a-*" {
+		t.Fatalf("name_pattern = %q, want team-a-*", created.NamePattern)
+	}
+	expectStatus(t, ts.upload(created.Key, storage.VisibilityPublic, "team-b-notes.txt", "b"), http.StatusForbidden)
+	ts.mustUpload(created.Key, storage.VisibilityPublic, "team-a-notes.txt", "a")
+}
+
+func TestServersAreIsolated(t *testing.T) {
+	first := newTestServer(t)
+	second := newTestServer(t)
+	key := first.seedKey(models.ScopeUpload, models.ScopeDownload)
+
+	first.mustUpload(key, storage.VisibilityPublic, "a.txt", "a")
+	expectStatus(t, second.upload(key, storage.VisibilityPublic, "a.txt", "a"), http.StatusUnauthorized)
+	expectStatus(t, second.get("/a.txt", ""), http.StatusNotFound)
+}
+
+func TestOnlyAdminKeysManageAdminKeys(t *testing.T) {
+	ts := newTestServer(t)
+	api := ts.seedKey(models.ScopeAPI)
+	admin := ts.seedKey(models.ScopeAdmin)
+	var list handlers.ApiKeyListResponse
+	decodeJSON(t, ts.get("/api-keys?scope=admin", admin), &list)
+	if list.Total != 1 {
+		t.Fatalf("admin keys = %+v", list)
+	}
+	id := strconv.FormatUint(uint64(list.Items[0].ID), 10)
+
+	expectStatus(t, ts.do(http.MethodDelete, "/api-keys/"+id, api, nil, nil), http.StatusForbidden)
+	ts.mustUpload(admin, storage.VisibilityPublic, "a.txt", "a")
+
+	// admin 密钥可以禁用 admin 密钥，但禁用后 api 密钥仍不能重新启用它
+	// An admin key can disable an admin key, and an api key still cannot enable it again
+	other := ts.seedKey(models.ScopeAdmin)
+	expectStatus(t, ts.do(http.MethodDelete, "/api-keys/"+id, other, nil, nil), http.StatusOK)
+	expectStatus(t, ts.do(http.MethodPost, "/api-keys/"+id+"/enable", api, nil, nil), http.StatusForbidden)
+	expectStatus(t, ts.upload(admin, storage.VisibilityPublic, "b.txt", "b"), http.StatusUnauthorized)
+}
+
+func TestRestrictedKeyManagesOnlyKeysWithinRestriction(t *testing.T) {
+	ts := newTestServer(t)
+	restrict := func(pattern string) func(*models.ApiKey) {
+		return func(k *models.ApiKey) { k.NamePattern = pattern }
+	}
+	restricted := ts.seedKeyWith(restrict("team-a-*"), models.ScopeAPI)
+	ts.seedKey(models.ScopeUpload)
+	ts.seedKeyWith(restrict("team-b-*"), models.ScopeUpload)
+	ts.seedKeyWith(restrict("team-a-*"), models.ScopeUpload)
+
+	admin := ts.seedKey(models.ScopeAdmin)
+	var list handlers.ApiKeyListResponse
+	decodeJSON(t, ts.get("/api-keys?scope=upload&sort=created_at&order=asc", admin), &list)
+	if list.Total != 3 {
+		t.Fatalf("upload keys = %+v", list)
+	}
+	for i, want := range []int{http.StatusForbidden, http.StatusForbidden, http.StatusOK} {
+		id := strconv.FormatUint(uint64(list.Items[i].ID), 10)
+		expectStatus(t, ts.do(http.MethodDelete, "/api-keys/"+id, restricted, nil, nil), want)
+		expectStatus(t, ts.do(http.MethodPost, "/api-keys/"+id+"/enable", restricted, nil, nil), want)
+	}
+}
+
+func TestAPIKeyListingShowsOnlyManageableKeys(t *testing.T) {
+	ts := newTestServer(t)
+	restricted := ts.seedKeyWith(func(k *models.ApiKey) { k.NamePattern = "team-a-*"; k.Label = "team-a" }, models.ScopeAPI)
+	api := ts.seedKeyWith(func(k *models.ApiKey) { k.Label = "api" }, models.ScopeAPI)
+	admin := ts.seedKeyWith(func(k *models.ApiKey) { k.Label = "admin" }, models.ScopeAdmin)
+	ts.seedKeyWith(func(k *models.ApiKey) { k.Label = "team-b" }, models.ScopeUpload)
+
+	labels := func(token string) map[string]bool {
+		t.Helper()
+		var list handlers.ApiKeyListResponse
+		decodeJSON(t, ts.get("/api-keys", token), &list)
+		seen := make(map[string]bool)
+		for _, item := range list.Items {
+			seen[item.Label] = true
+		}
+		if int(list.Total) != len(seen) {
+			t.Fatalf("total = %d, items = %v", list.Total, seen)
+		}
+		return seen
+	}
+
+	if seen := labels(admin); len(seen) != 4 {
+		t.Fatalf("admin sees %v", seen)
+	}
+	if seen := labels(api); len(seen) != 3 || seen["admin"] {
+		t.Fatalf("api key sees %v", seen)
+	}
+	if seen := labels(restricted); len(seen) != 1 || !seen["team-a"] {
+		t.Fatalf("restricted key sees %v", seen)
+	}
+}
+
+func TestUsageRequiresAdmin(t *testing.T) {
+	ts := newTestServer(t)
+
+	expectStatus(t, ts.get("/api/usage", ts.seedKey(models.ScopeAPI)), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/api/usage", ts.seedKey(models.ScopeAdmin)), http.StatusOK)
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+func TestAuditRecordsManagementActions(t *testing.T) {
+	ts := newTestServer(t)
+	key := ts.seedKey(models.ScopeUpload, models.ScopeDownload, models.ScopeShorten, models.ScopeDelete)
+	ts.mustUpload(key, storage.VisibilityPrivate, "report.pdf", "report")
+	ts.mustUpload(key, storage.VisibilityPublic, "old.txt", "old")
+
+	rec := ts.sendJSON(http.MethodPost, "/api/signed-urls", key, handlers.CreateSignedURLRequest{Filename: "report.pdf"})
+	expectStatus(t, rec, http.StatusOK)
+	code := ts.shorten(key, handlers.CreateShortLinkRequest{Filename: "report.pdf"})
+	visibility := "public"
+	rec = ts.sendJSON(http.MethodPatch, "/api/shortlinks/"+code, key, handlers.UpdateShortLinkRequest{Visibility: &visibility})
+	expectStatus(t, rec, http.StatusOK)
+	expectStatus(t, ts.do(http.MethodDelete, "/api/files/old.txt?visibility=public", key, nil, nil), http.StatusOK)
+	location := ts.createTusUpload(key, "abandoned.txt", 10)
+	expectStatus(t, ts.do(http.MethodDelete, location, key, nil, tusHeader()), http.StatusNoContent)
+
+	var logs handlers.AuditLogListResponse
+	decodeJSON(t, ts.get("/api/audit?action=signed_url.create,short_link.update,file.delete,upload.terminate", ts.seedKey(models.ScopeAdmin)), &logs)
+	got := make(map[string]string)
+	for _, entry := range logs.Items {
+		if entry.Outcome != "success" {
+			t.Errorf("%s on %s: outcome %s", entry.Action, entry.Target, entry.Outcome)
+		}
+		got[entry.Action] = entry.Target
+	}
+	want := map[string]string{
+		"signed_url.create": "private/report.pdf",
+		"short_link.update": "short_link/" + code,
+		"file.delete":       "public/old.txt",
+		"upload.terminate":  "upload/" + strings.TrimPrefix(location, "/uploads/"),
+	}
+	for action, target := range want {
+		if got[action] != target {
+			t.Errorf("%s target = %q, want %q", action, got[action], target)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// transferDeadlines 为每个请求设置读写截止时间，并在每次收到或发出数据时顺延；
+// 与 http.Server 的 ReadTimeout 和 WriteTimeout 不同，它只限制传输停顿的时长，大文件传输不会因总时长被截断
+// transferDeadlines sets read and write deadlines for each request and pushes them back whenever data moves;
+// unlike the ReadTimeout and WriteTimeout of http.Server it only bounds how long a transfer may stall,
+// so large transfers are never cut off for taking long overall
+type transferDeadlines struct {
+	next         http.Handler
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+}
+
+func (d transferDeadlines) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	// 1. 清除上一个请求在连接上留下的写截止时间；处理请求本身不受写超时限制
+	// 1. Clear the write deadline the previous request left on the connection; handling the request is not bound by it
+	if d.writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Time{})
+		w = &deadlineWriter{ResponseWriter: w, rc: rc, timeout: d.writeTimeout}
+	}
+
+	// 2. 只为带请求体的请求设置读截止时间；没有请求体时 net/http 会在后台读取连接，
+	//    此时的截止时间会被当作客户端断开，从而取消请求的上下文
+	// 2. Only requests with a body get a read deadline; without one net/http reads the connection in the background,
+	//    where an expired deadline counts as the client going away and cancels the request context
+	if d.readTimeout > 0 && r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		rc.SetReadDeadline(time.Now().Add(d.readTimeout))
+		r.Body = &deadlineReader{ReadCloser: r.Body, rc: rc, timeout: d.readTimeout}
+	}
+
+	d.next.ServeHTTP(w, r)
+
+	// 3. 为处理结束后 net/http 写出缓冲的响应留出时间
+	// 3. Leave time for net/http to flush the buffered response once the handler returns
+	if d.writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Now().Add(d.writeTimeout))
+	}
+}
+
+// deadlineWriter 在每次写入前顺延写截止时间
+// deadlineWriter pushes the write deadline back before every write
+type deadlineWriter struct {
+	http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (w *deadlineWriter) WriteHeader(status int) {
+	w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *deadlineWriter) Write(p []byte) (int, error) {
+	w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush 使 gin 的流式响应仍能刷新缓冲区
+// Flush keeps gin's streaming responses able to flush the buffer
+func (w *deadlineWriter) Flush() {
+	w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	w.rc.Flush()
+}
+
+// Unwrap 让 http.ResponseController 能找到底层的 ResponseWriter
+// Unwrap lets http.ResponseController reach the underlying ResponseWriter
+func (w *deadlineWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// deadlineReader 在每次读取前顺延读截止时间，读完请求体后清除它
+// deadlineReader pushes the read deadline back before every read and clears it once the body is consumed
+type deadlineReader struct {
+	io.ReadCloser
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (r *deadlineReader) Read(p []byte) (int, error) {
+	r.rc.SetReadDeadline(time.Now().Add(r.timeout))
+	n, err := r.ReadCloser.Read(p)
+	if errors.Is(err, io.EOF) {
+		// 读完后 net/http 开始在后台读取连接，不能让截止时间在那里触发
+		// Once the body is consumed net/http starts reading the connection in the background, where the deadline must not fire
+		r.rc.SetReadDeadline(time.Time{})
+	}
+	return n, err
+}
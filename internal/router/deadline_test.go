@@ -0,0 +1,66 @@
+package router
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransferDeadlinesAllowSlowSteadyTransfers(t *testing.T) {
+	// 总时长超过写超时，但每次写入之间的停顿都更短
+	// The whole response takes longer than the write timeout, but every pause between writes is shorter
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 6 {
+			io.WriteString(w, "chunk\n")
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	})
+	server := httptest.NewServer(transferDeadlines{next: handler, readTimeout: 250 * time.Millisecond, writeTimeout: 250 * time.Millisecond})
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read body: %v", err)
+	}
+	if want := strings.Repeat("chunk\n", 6); string(body) != want {
+		t.Fatalf("body = %q, want %q", body, want)
+	}
+}
+
+func TestTransferDeadlinesCutOffStalledUploads(t *testing.T) {
+	readErr := make(chan error, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.ReadAll(r.Body)
+		readErr <- err
+	})
+	server := httptest.NewServer(transferDeadlines{next: handler, readTimeout: 200 * time.Millisecond})
+	defer server.Close()
+
+	// 声明的请求体比实际发送的长，然后停止发送
+	// Declare a longer body than is sent, then stop sending
+	conn, err := net.Dial("tcp", server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "POST / HTTP/1.1\r\nHost: gofi\r\nContent-Length: 100\r\n\r\npartial")
+
+	select {
+	case err := <-readErr:
+		if err == nil {
+			t.Fatal("reading a stalled body succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stalled upload was not cut off")
+	}
+}
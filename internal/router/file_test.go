@@ -0,0 +1,288 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+func TestHealthCheck(t *testing.T) {
+	ts := newTestServer(t)
+	expectStatus(t, ts.get("/health", ""), http.StatusOK)
+}
+
+func TestUploadRequiresUploadScope(t *testing.T) {
+	ts := newTestServer(t)
+	download := ts.seedKey(models.ScopeDownload)
+	disabled := ts.seedKeyWith(func(k *models.ApiKey) { k.IsEnabled = false }, models.ScopeUpload)
+	expired := ts.seedKeyWith(func(k *models.ApiKey) { k.ExpiresAt = past() }, models.ScopeUpload)
+
+	for name, token := range map[string]string{
+		"missing":     "",
+		"unknown":     "gofi_nope_0000000000000000",
+		"wrong scope": download,
+		"disabled":    disabled,
+		"expired":     expired,
+	} {
+		t.Run(name, func(t *testing.T) {
+			expectStatus(t, ts.upload(token, storage.VisibilityPrivate, "a.txt", "data"), http.StatusUnauthorized)
+		})
+	}
+
+	expectStatus(t, ts.upload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "a.txt", "data"), http.StatusOK)
+	expectStatus(t, ts.upload(ts.seedKey(models.ScopeAdmin), storage.VisibilityPrivate, "b.txt", "data"), http.StatusOK)
+}
+
+func TestUploadStoresFileInTargetArea(t *testing.T) {
+	// 使用本地文件系统后端，检查文件在基础目录下的实际位置
+	// Use the local filesystem backend to check where files actually land under the base directory
+	ts := newTestServer(t, withBackend("localfs"))
+	upload := ts.seedKey(models.ScopeUpload)
+
+	ts.mustUpload(upload, storage.VisibilityPublic, "pub.txt", "public data")
+	ts.mustUpload(upload, storage.VisibilityPrivate, "priv.txt", "private data")
+
+	for path, want := range map[string]string{
+		filepath.Join(ts.baseDir, "public", "pub.txt"):   "public data",
+		filepath.Join(ts.baseDir, "private", "priv.txt"): "private data",
+	} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestUploadRejectsExistingName(t *testing.T) {
+	ts := newTestServer(t)
+	upload := ts.seedKey(models.ScopeUpload)
+
+	ts.mustUpload(upload, storage.VisibilityPublic, "a.txt", "first")
+	expectStatus(t, ts.upload(upload, storage.VisibilityPublic, "a.txt", "second"), http.StatusConflict)
+	expectBody(t, ts.get("/a.txt", ""), "first")
+}
+
+func TestPublicDownloadNeedsNoToken(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "pub.txt", "public data")
+
+	expectBody(t, ts.get("/pub.txt", ""), "public data")
+	expectStatus(t, ts.get("/missing.txt", ""), http.StatusNotFound)
+}
+
+func TestPrivateDownloadRequiresDownloadScope(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "priv.txt", "private data")
+	download := ts.seedKey(models.ScopeDownload)
+
+	expectStatus(t, ts.get("/priv.txt", ""), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/priv.txt", ts.seedKey(models.ScopeUpload)), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/priv.txt?token=not-a-key", ""), http.StatusUnauthorized)
+
+	expectBody(t, ts.get("/priv.txt", download), "private data")
+	expectBody(t, ts.get("/priv.txt?token="+download, ""), "private data")
+}
+
+func TestPathRestrictedKeyOnlyReachesMatchingFiles(t *testing.T) {
+	ts := newTestServer(t)
+	upload := ts.seedKey(models.ScopeUpload)
+	ts.mustUpload(upload, storage.VisibilityPrivate, "team-a-report.txt", "a")
+	ts.mustUpload(upload, storage.VisibilityPrivate, "team-b-report.txt", "b")
+	teamA := ts.seedKeyWith(func(k *models.ApiKey) { k.NamePattern = "team-a-*" }, models.ScopeDownload, models.ScopeUpload)
+
+	expectBody(t, ts.get("/team-a-report.txt", teamA), "a")
+	expectStatus(t, ts.get("/team-b-report.txt", teamA), http.StatusForbidden)
+	expectStatus(t, ts.upload(teamA, storage.VisibilityPrivate, "team-b-notes.txt", "x"), http.StatusForbidden)
+}
+
+func TestUploadPathTraversal(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			ts := newTestServer(t, withBackend(backend))
+			upload := ts.seedKey(models.ScopeUpload)
+
+			// 目录部分被丢弃，文件只会落在目标区域中
+			// The directory part is dropped, so the file only ever lands in the target area
+			for _, name := range []string{"../../escape.txt", `..\..\escape.txt`, "/etc/escape.txt"} {
+				t.Run(name, func(t *testing.T) {
+					rec := ts.upload(upload, storage.VisibilityPrivate, name, "x")
+					if rec.Code == http.StatusOK {
+						var resp struct {
+							Filename string `json:"filename"`
+						}
+						decodeJSON(t, rec, &resp)
+						if !storage.ValidName(resp.Filename) {
+							t.Fatalf("stored as %q", resp.Filename)
+						}
+						ts.do(http.MethodDelete, "/api/files/"+resp.Filename+"?visibility=private", ts.seedKey(models.ScopeDelete), nil, nil)
+					} else {
+						expectStatus(t, rec, http.StatusBadRequest)
+					}
+				})
+			}
+			for _, name := range []string{"..", "."} {
+				t.Run(name, func(t *testing.T) {
+					expectStatus(t, ts.upload(upload, storage.VisibilityPrivate, name, "x"), http.StatusBadRequest)
+				})
+			}
+
+			// 所有上传都已删除，存储中和基础目录外都不应留下任何文件
+			// Every upload was deleted, so nothing should be left in storage or outside the base directory
+			for _, visibility := range []storage.Visibility{storage.VisibilityPublic, storage.VisibilityPrivate} {
+				objects, err := ts.srv.Storage.List(context.Background(), visibility)
+				if err != nil {
+					t.Fatalf("list %s: %v", visibility, err)
+				}
+				if len(objects) != 0 {
+					t.Errorf("%s still holds %+v", visibility, objects)
+				}
+			}
+			parent := filepath.Dir(ts.baseDir)
+			for _, path := range []string{filepath.Join(parent, "escape.txt"), filepath.Join(ts.baseDir, "escape.txt")} {
+				if _, err := os.Stat(path); !os.IsNotExist(err) {
+					t.Errorf("%s exists outside the storage areas", path)
+				}
+			}
+		})
+	}
+}
+
+func TestDownloadPathTraversal(t *testing.T) {
+	for _, backend := range testBackends {
+		t.Run(backend, func(t *testing.T) {
+			ts := newTestServer(t, withBackend(backend))
+			ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "secret.txt", "secret")
+			if err := os.WriteFile(filepath.Join(filepath.Dir(ts.baseDir), "outside.txt"), []byte("outside"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, target := range []string{
+				"/..%2foutside.txt",
+				"/..%2f..%2foutside.txt",
+				"/%2e%2e%2foutside.txt",
+				"/public%2f..%2fprivate%2fsecret.txt",
+				"/private%2fsecret.txt",
+				"/..%5coutside.txt",
+			} {
+				t.Run(target, func(t *testing.T) {
+					rec := ts.get(target, "")
+					if rec.Code == http.StatusOK {
+						t.Fatalf("served %q", rec.Body.String())
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestDeleteFileRequiresDeleteScope(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "pub.txt", "data")
+
+	expectStatus(t, ts.do(http.MethodDelete, "/api/files/pub.txt?visibility=public", ts.seedKey(models.ScopeUpload), nil, nil), http.StatusUnauthorized)
+	expectBody(t, ts.get("/pub.txt", ""), "data")
+
+	delete := ts.seedKey(models.ScopeDelete)
+	if rec := ts.do(http.MethodDelete, "/api/files/..%2fpublic%2fpub.txt?visibility=public", delete, nil, nil); rec.Code == http.StatusOK {
+		t.Fatalf("deleted through a traversal path: %s", rec.Body.String())
+	}
+	expectBody(t, ts.get("/pub.txt", ""), "data")
+
+	expectStatus(t, ts.do(http.MethodDelete, "/api/files/pub.txt?visibility=public", delete, nil, nil), http.StatusOK)
+	expectStatus(t, ts.get("/pub.txt", ""), http.StatusNotFound)
+	if _, err := ts.srv.Storage.Stat(context.Background(), storage.VisibilityPublic, "pub.txt"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("stored object still exists: %v", err)
+	}
+}
+
+func TestOverwriteDoesNotCountReplacedFile(t *testing.T) {
+	ts := newTestServer(t, func(cfg *config.Config) { cfg.UploadConflictPolicy = string(handlers.ConflictOverwrite) })
+	quota := int64(1000)
+	key := ts.seedKeyWith(func(k *models.ApiKey) { k.StorageQuota = &quota }, models.ScopeUpload)
+
+	ts.mustUpload(key, storage.VisibilityPublic, "a.txt", strings.Repeat("a", 600))
+	ts.mustUpload(key, storage.VisibilityPublic, "a.txt", strings.Repeat("b", 600))
+	expectStatus(t, ts.upload(key, storage.VisibilityPublic, "b.txt", strings.Repeat("c", 600)), http.StatusRequestEntityTooLarge)
+}
+
+func TestConcurrentUploadsStayWithinQuota(t *testing.T) {
+	ts := newTestServer(t)
+	quota := int64(1000)
+	key := ts.seedKeyWith(func(k *models.ApiKey) { k.StorageQuota = &quota }, models.ScopeUpload)
+
+	// 每个上传单独都能通过读取请求体之前的检查，但合计超出配额
+	// Each upload passes the check made before the body is read on its own, but together they exceed the quota
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts.upload(key, storage.VisibilityPublic, fmt.Sprintf("part-%d.txt", i), strings.Repeat("p", 300))
+		}()
+	}
+	wg.Wait()
+
+	used, err := ts.srv.Files.UsedBytes(nil)
+	if err != nil {
+		t.Fatalf("used bytes: %v", err)
+	}
+	if used > quota {
+		t.Fatalf("used %d bytes, quota is %d", used, quota)
+	}
+}
+
+// failingPut 包装存储后端，让第一次写入 name 失败
+// failingPut wraps a storage backend so the first write to name fails
+type failingPut struct {
+	storage.Backend
+	name   string
+	failed bool
+}
+
+func (f *failingPut) Put(ctx context.Context, visibility storage.Visibility, name string, r io.Reader) (storage.ObjectInfo, error) {
+	if name == f.name && !f.failed {
+		f.failed = true
+		return storage.ObjectInfo{}, errors.New("disk full")
+	}
+	return f.Backend.Put(ctx, visibility, name, r)
+}
+
+func TestFailedVersionUploadKeepsOriginal(t *testing.T) {
+	ts := newTestServer(t, func(cfg *config.Config) { cfg.UploadConflictPolicy = string(handlers.ConflictVersion) })
+	upload := ts.seedKey(models.ScopeUpload)
+	ts.mustUpload(upload, storage.VisibilityPublic, "doc.txt", "v1")
+
+	// 归档旧文件后写入新内容失败，归档被撤销
+	// Writing the new content fails after the old file was archived, so the archive is undone
+	ts.srv.Storage = &failingPut{Backend: ts.srv.Storage, name: "doc.txt"}
+	expectStatus(t, ts.upload(upload, storage.VisibilityPublic, "doc.txt", "v2"), http.StatusInternalServerError)
+
+	expectBody(t, ts.get("/doc.txt", ""), "v1")
+	if _, err := ts.srv.Files.Find("doc.v1.txt", string(storage.VisibilityPublic)); err == nil {
+		t.Error("archive record was left behind")
+	}
+	if _, err := ts.srv.Storage.Stat(context.Background(), storage.VisibilityPublic, "doc.v1.txt"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("archived object was left behind: %v", err)
+	}
+
+	// 之后的上传照常归档
+	// Later uploads archive as usual
+	ts.mustUpload(upload, storage.VisibilityPublic, "doc.txt", "v2")
+	expectBody(t, ts.get("/doc.txt", ""), "v2")
+	expectBody(t, ts.get("/doc.v1.txt", ""), "v1")
+}
@@ -0,0 +1,249 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/database"
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+)
+
+func TestMain(m *testing.M) {
+	// 测试中不输出路由表和访问日志
+	// Keep the route table and access log out of test output
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	os.Exit(m.Run())
+}
+
+// memoryBackend 表示内存存储后端，是测试实例默认使用的后端
+// memoryBackend names the in-memory storage backend, which test instances use by default
+const memoryBackend = "memory"
+
+// testBackends 是需要在每种后端上运行的测试所用的后端；localfs 在临时基础目录下读写真实文件
+// testBackends are the backends for tests that must run on each of them; localfs reads and writes real files under the temporary base directory
+var testBackends = []string{memoryBackend, "localfs"}
+
+// testServer 是一个独立的 GoFi 实例：临时基础目录、存储后端（默认在内存中）、内存 SQLite 数据库和完整的路由
+// testServer is an isolated GoFi instance: a temporary base directory, a storage backend (in memory by default),
+// an in-memory SQLite database and the full router
+type testServer struct {
+	t       *testing.T
+	baseDir string
+	srv     *handlers.Server
+	router  *gin.Engine
+}
+
+// withBackend 让测试实例使用名为 backend 的存储后端
+// withBackend makes the test instance use the storage backend named backend
+func withBackend(backend string) func(*config.Config) {
+	return func(cfg *config.Config) { cfg.StorageBackend = backend }
+}
+
+// newTestServer 创建测试实例，测试结束时自动关闭；configure 在创建前调整配置
+// newTestServer creates a test instance that is closed when the test ends; configure adjusts the configuration before creation
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(configPath, nil, 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.DatabaseURL = "sqlite::memory:"
+	cfg.DatabaseDriver = ""
+	cfg.DatabaseAutoMigrate = true
+	cfg.GoFiBaseDir = filepath.Join(dir, "data")
+	cfg.StorageBackend = memoryBackend
+	cfg.SecretKey = "test-secret"
+	for _, fn := range configure {
+		fn(cfg)
+	}
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	var store storage.Backend = storage.NewMemory()
+	if cfg.StorageBackend != memoryBackend {
+		if store, err = storage.New(cfg); err != nil {
+			t.Fatalf("init storage: %v", err)
+		}
+	}
+	srv, err := handlers.NewServer(cfg, db, store)
+	if err != nil {
+		t.Fatalf("init server: %v", err)
+	}
+	t.Cleanup(func() {
+		srv.Close()
+		database.Close(db)
+	})
+
+	router, err := SetupRouter(srv)
+	if err != nil {
+		t.Fatalf("set up router: %v", err)
+	}
+	return &testServer{t: t, baseDir: cfg.GoFiBaseDir, srv: srv, router: router}
+}
+
+// seedKey 直接在数据库中创建拥有 scopes 的 API Key，返回完整密钥
+// seedKey creates an API key with scopes directly in the database and returns the full key
+func (ts *testServer) seedKey(scopes ...models.Scope) string {
+	ts.t.Helper()
+	return ts.seedKeyWith(func(*models.ApiKey) {}, scopes...)
+}
+
+// seedKeyWith 与 seedKey 相同，但在保存前由 edit 修改记录
+// seedKeyWith is seedKey with edit adjusting the record before it is saved
+func (ts *testServer) seedKeyWith(edit func(*models.ApiKey), scopes ...models.Scope) string {
+	ts.t.Helper()
+	key, prefix, err := utility.GenerateAPIKey()
+	if err != nil {
+		ts.t.Fatalf("generate key: %v", err)
+	}
+	apiKey := models.ApiKey{Prefix: prefix, KeyHash: models.HashAPIKey(key), IsEnabled: true}
+	apiKey.SetScopes(scopes)
+	edit(&apiKey)
+	enabled := apiKey.IsEnabled
+	if err := ts.srv.APIKeys.Create(&apiKey); err != nil {
+		ts.t.Fatalf("seed key: %v", err)
+	}
+	// 创建时 false 会被列默认值 true 取代，需要单独禁用
+	// On create a false is replaced by the column default of true, so disabling takes a separate update
+	if !enabled {
+		if err := ts.srv.APIKeys.SetEnabled(&apiKey, false); err != nil {
+			ts.t.Fatalf("disable key: %v", err)
+		}
+	}
+	return key
+}
+
+// do 发送请求并返回记录的响应；token 非空时作为 Bearer Token 发送
+// do sends a request and returns the recorded response; a non-empty token is sent as a Bearer token
+func (ts *testServer) do(method, target, token string, body io.Reader, header http.Header) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	req := httptest.NewRequest(method, target, body)
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	ts.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// get 发送 GET 请求
+// get sends a GET request
+func (ts *testServer) get(target, token string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	return ts.do(http.MethodGet, target, token, nil, nil)
+}
+
+// sendJSON 以 JSON 请求体发送请求
+// sendJSON sends a request with a JSON body
+func (ts *testServer) sendJSON(method, target, token string, body any) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		ts.t.Fatalf("encode body: %v", err)
+	}
+	header := http.Header{"Content-Type": {"application/json"}}
+	return ts.do(method, target, token, bytes.NewReader(data), header)
+}
+
+// upload 以 multipart 表单上传文件到 visibility 区域
+// upload uploads a file to the visibility area as a multipart form
+func (ts *testServer) upload(token string, visibility storage.Visibility, filename, content string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", filename)
+	if err != nil {
+		ts.t.Fatalf("create form file: %v", err)
+	}
+	io.WriteString(part, content)
+	form.Close()
+
+	header := http.Header{
+		"Content-Type":      {form.FormDataContentType()},
+		"X-GoFi-Target-Dir": {string(visibility)},
+	}
+	return ts.do(http.MethodPost, "/upload", token, &body, header)
+}
+
+// mustUpload 上传文件，失败时终止测试
+// mustUpload uploads a file and stops the test on failure
+func (ts *testServer) mustUpload(token string, visibility storage.Visibility, filename, content string) {
+	ts.t.Helper()
+	rec := ts.upload(token, visibility, filename, content)
+	expectStatus(ts.t, rec, http.StatusOK)
+}
+
+// shorten 为文件创建短链接，返回短代码
+// shorten creates a short link for a file and returns the short code
+func (ts *testServer) shorten(token string, req handlers.CreateShortLinkRequest) string {
+	ts.t.Helper()
+	rec := ts.sendJSON(http.MethodPost, "/shorten", token, req)
+	expectStatus(ts.t, rec, http.StatusOK)
+	var resp struct {
+		ShortURLPath string `json:"short_url_path"`
+	}
+	decodeJSON(ts.t, rec, &resp)
+	return filepath.Base(resp.ShortURLPath)
+}
+
+// expectStatus 检查响应状态码，不符时输出响应体
+// expectStatus checks the response status and prints the body when it differs
+func expectStatus(t *testing.T, rec *httptest.ResponseRecorder, want int) {
+	t.Helper()
+	if rec.Code != want {
+		t.Fatalf("status = %d, want %d; body: %s", rec.Code, want, rec.Body.String())
+	}
+}
+
+// expectBody 检查响应状态码为 200 且响应体为 want
+// expectBody checks that the response is a 200 with want as its body
+func expectBody(t *testing.T, rec *httptest.ResponseRecorder, want string) {
+	t.Helper()
+	expectStatus(t, rec, http.StatusOK)
+	if got := rec.Body.String(); got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
+
+// decodeJSON 将 JSON 响应体解析到 v
+// decodeJSON decodes the JSON response body into v
+func decodeJSON(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+		t.Fatalf("decode %s: %v", rec.Body.String(), err)
+	}
+}
+
+// past 返回一小时前的时间，用于构造已过期的记录
+// past returns the time an hour ago, for building expired records
+func past() *time.Time {
+	t := time.Now().Add(-time.Hour)
+	return &t
+}
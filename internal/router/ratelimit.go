@@ -0,0 +1,370 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"gorm.io/gorm"
+)
+
+const (
+	// keyPolicyTTL 是缓存的 API Key 限流设置的有效期，过期后重新从数据库读取
+	// keyPolicyTTL is how long cached API key limits stay valid before they are read from the database again
+	keyPolicyTTL = time.Minute
+	// idleLimiterTTL 是空闲限流器被清理前保留的时间
+	// idleLimiterTTL is how long an idle limiter is kept before it is swept
+	idleLimiterTTL = 10 * time.Minute
+	// maxClients 是限流表最多保存的客户端数；API Key 只有存在时才会加入，因此只有客户端 IP 会受此限制
+	// maxClients is the most clients the table holds; API keys are only added when they exist, so only client IPs run into it
+	maxClients = 100000
+)
+
+// limitPolicy 描述一个客户端的限流设置，0 表示不限制
+// limitPolicy describes the limits of one client, 0 means unlimited
+type limitPolicy struct {
+	Requests  float64 // 每秒请求数 / Requests per second
+	Burst     int     // 突发请求数，0 表示与每秒请求数相同 / Request burst, 0 means the same as requests per second
+	Bandwidth int64   // 每秒字节数 / Bytes per second
+}
+
+// buckets 是一个客户端的令牌桶，为空表示不限制
+// buckets holds the token buckets of one client, nil means unlimited
+type buckets struct {
+	requests  *rate.Limiter
+	bandwidth *rate.Limiter
+}
+
+// clientLimiter 是一个 API Key 或客户端 IP 的限流状态
+// clientLimiter is the rate limiting state of one API key or client IP
+type clientLimiter struct {
+	buckets
+	policy   limitPolicy
+	applied  bool
+	loadedAt time.Time
+	lastSeen time.Time
+}
+
+// apply 按新的设置重建令牌桶；设置未改变时保持当前状态
+// apply rebuilds the buckets for a new policy; an unchanged policy keeps the current state
+func (l *clientLimiter) apply(p limitPolicy) {
+	if l.applied && p == l.policy {
+		return
+	}
+	l.policy, l.applied = p, true
+
+	l.requests = nil
+	if p.Requests > 0 {
+		burst := p.Burst
+		if burst <= 0 {
+			burst = max(1, int(math.Ceil(p.Requests)))
+		}
+		l.requests = rate.NewLimiter(rate.Limit(p.Requests), burst)
+	}
+	l.bandwidth = nil
+	if p.Bandwidth > 0 {
+		// 桶容量为一秒的流量，同时也是单次读写的最大分片
+		// The bucket holds one second of traffic, which is also the largest chunk per read or write
+		l.bandwidth = rate.NewLimiter(rate.Limit(p.Bandwidth), int(min(p.Bandwidth, math.MaxInt32)))
+	}
+}
+
+// rateLimiter 按 API Key 和客户端 IP 应用令牌桶限流
+// rateLimiter applies token bucket limits per API key and per client IP
+type rateLimiter struct {
+	keys       *repository.ApiKeyRepo
+	keyDefault limitPolicy
+	ipDefault  limitPolicy
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	overflow  clientLimiter // 限流表已满时新 IP 共用的状态 / State shared by new IPs once the table is full
+	lastSweep time.Time
+}
+
+// newRateLimiter 根据配置创建限流器，API Key 的限流设置从 keys 中读取
+// newRateLimiter creates the rate limiter from the configuration, reading API key limits from keys
+func newRateLimiter(cfg *config.Config, keys *repository.ApiKeyRepo) *rateLimiter {
+	return &rateLimiter{
+		keys:       keys,
+		keyDefault: limitPolicy{Requests: cfg.RateLimitKeyRequests, Burst: cfg.RateLimitKeyBurst, Bandwidth: cfg.RateLimitKeyBandwidth},
+		ipDefault:  limitPolicy{Requests: cfg.RateLimitIPRequests, Burst: cfg.RateLimitIPBurst, Bandwidth: cfg.RateLimitIPBandwidth},
+		clients:    make(map[string]*clientLimiter),
+	}
+}
+
+// Handler 是限流中间件：超出请求数或带宽透支时返回 429 和 Retry-After，否则按带宽限制收发数据
+// Handler is the rate limiting middleware: it answers 429 with Retry-After when requests are exceeded
+// or the bandwidth is overdrawn, and otherwise paces the request and response bodies to the bandwidth limit
+func (rl *rateLimiter) Handler(c *gin.Context) {
+	now := time.Now()
+
+	// 1. 先按客户端 IP 限流，被拒绝的请求不会查询 API Key
+	// 1. Limit by client IP first, so rejected requests never look up the API key
+	ip := rl.ipBuckets(c.ClientIP(), now)
+	ipReservation, retryAfter := ip.reserve(now)
+	if retryAfter > 0 {
+		abortRateLimited(c, retryAfter)
+		return
+	}
+	limits := []buckets{ip}
+
+	// 2. 再按 API Key 限流；需要等待时归还 IP 令牌桶中的预留并拒绝
+	// 2. Then limit by API key; if it would have to wait, hand the IP reservation back and reject
+	if key, ok := rl.keyBuckets(c, now); ok {
+		if _, retryAfter := key.reserve(now); retryAfter > 0 {
+			if ipReservation != nil {
+				ipReservation.CancelAt(now)
+			}
+			abortRateLimited(c, retryAfter)
+			return
+		}
+		limits = append(limits, key)
+	}
+
+	// 3. 按带宽限制收发请求体和响应体
+	// 3. Pace the request and response bodies to the bandwidth limits
+	var bandwidth []*rate.Limiter
+	for _, b := range limits {
+		if b.bandwidth != nil {
+			bandwidth = append(bandwidth, b.bandwidth)
+		}
+	}
+	if len(bandwidth) > 0 {
+		ctx := c.Request.Context()
+		if c.Request.Body != nil {
+			c.Request.Body = &throttledReader{ReadCloser: c.Request.Body, ctx: ctx, limiters: bandwidth}
+		}
+		c.Writer = &throttledWriter{ResponseWriter: c.Writer, ctx: ctx, limiters: bandwidth}
+	}
+	c.Next()
+}
+
+// abortRateLimited 以 429 和 Retry-After 拒绝请求
+// abortRateLimited rejects the request with 429 and Retry-After
+func abortRateLimited(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Rate limit exceeded"})
+}
+
+// reserve 在令牌桶中预留一次请求并返回需要等待的时间；需要等待时预留会被取消
+// reserve reserves one request in the buckets and returns how long it would have to wait; the reservation is cancelled when it would
+func (b buckets) reserve(now time.Time) (*rate.Reservation, time.Duration) {
+	var reservation *rate.Reservation
+	var retryAfter time.Duration
+	if b.requests != nil {
+		reservation = b.requests.ReserveN(now, 1)
+		retryAfter = reservation.DelayFrom(now)
+	}
+	if b.bandwidth != nil {
+		// 其他请求已预支带宽时，新的请求需要等到透支还清
+		// When other requests have already borrowed bandwidth, new requests wait until the overdraft is paid back
+		if tokens := b.bandwidth.TokensAt(now); tokens < 0 {
+			retryAfter = max(retryAfter, time.Duration(-tokens/float64(b.bandwidth.Limit())*float64(time.Second)))
+		}
+	}
+	if retryAfter > 0 && reservation != nil {
+		reservation.CancelAt(now)
+		reservation = nil
+	}
+	return reservation, retryAfter
+}
+
+// ipBuckets 返回客户端 IP 的令牌桶；限流表已满时，新的 IP 共用一组溢出令牌桶
+// ipBuckets returns the buckets of a client IP; once the table is full, new IPs share one set of overflow buckets
+func (rl *rateLimiter) ipBuckets(ip string, now time.Time) buckets {
+	if rl.ipDefault == (limitPolicy{}) {
+		return buckets{}
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.sweep(now)
+	id := "ip:" + ip
+	if _, ok := rl.clients[id]; !ok && len(rl.clients) >= maxClients {
+		rl.overflow.apply(rl.ipDefault)
+		rl.overflow.lastSeen = now
+		return rl.overflow.buckets
+	}
+	l := rl.client(id, now)
+	l.apply(rl.ipDefault)
+	return l.buckets
+}
+
+// keyBuckets 返回请求所带 API Key 的令牌桶；没有 Token，或密钥不存在、已禁用、已过期时返回 false
+// keyBuckets returns the buckets of the API key the request carries; it returns false without a token or for unknown,
+// disabled and expired keys
+func (rl *rateLimiter) keyBuckets(c *gin.Context, now time.Time) (buckets, bool) {
+	token := utility.RequestToken(c)
+	if token == "" {
+		return buckets{}, false
+	}
+	keyHash := models.HashAPIKey(token)
+	id := "key:" + keyHash
+
+	rl.mu.Lock()
+	if l, ok := rl.clients[id]; ok && now.Sub(l.loadedAt) < keyPolicyTTL {
+		l.lastSeen = now
+		b := l.buckets
+		rl.mu.Unlock()
+		return b, true
+	}
+	rl.mu.Unlock()
+
+	// 在锁外读取 API Key 的设置，避免持锁查询数据库；不存在或不可用的密钥不会被缓存，伪造的 Token 无法占满限流表
+	// Load the API key's policy outside the lock so the database is never queried while holding it;
+	// unknown and unusable keys are not cached, so made-up tokens cannot fill the table
+	policy, found := rl.loadKeyPolicy(keyHash, now)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.sweep(now)
+	if !found {
+		delete(rl.clients, id)
+		return buckets{}, false
+	}
+	l := rl.client(id, now)
+	l.apply(policy)
+	l.loadedAt = now
+	return l.buckets, true
+}
+
+// client 返回 id 的限流状态，不存在时创建；调用方需持有锁
+// client returns the limiting state for id, creating it when missing; the caller must hold the lock
+func (rl *rateLimiter) client(id string, now time.Time) *clientLimiter {
+	l, ok := rl.clients[id]
+	if !ok {
+		l = &clientLimiter{}
+		rl.clients[id] = l
+	}
+	l.lastSeen = now
+	return l
+}
+
+// loadKeyPolicy 读取 API Key 的限流设置，未设置的字段使用默认值；密钥不存在、已禁用或已过期时返回 false，
+// 这样的请求只按客户端 IP 限流
+// loadKeyPolicy reads the API key's limits, using the defaults for fields it does not override; it returns false for
+// unknown, disabled and expired keys, whose requests are only limited by client IP
+func (rl *rateLimiter) loadKeyPolicy(keyHash string, now time.Time) (limitPolicy, bool) {
+	policy := rl.keyDefault
+
+	apiKey, err := rl.keys.FindByHash(keyHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return policy, false
+	}
+	if err != nil {
+		// 数据库故障时仍按默认值限流
+		// Keep applying the defaults while the database is failing
+		return policy, true
+	}
+	if !apiKey.IsEnabled || apiKey.IsExpired(now) {
+		return policy, false
+	}
+
+	if apiKey.RateLimitRequests != nil {
+		policy.Requests = *apiKey.RateLimitRequests
+	}
+	if apiKey.RateLimitBurst != nil {
+		policy.Burst = *apiKey.RateLimitBurst
+	}
+	if apiKey.RateLimitBandwidth != nil {
+		policy.Bandwidth = *apiKey.RateLimitBandwidth
+	}
+	return policy, true
+}
+
+// sweep 每分钟清理一次长时间未使用的限流器，限流表已满时每秒一次；调用方需持有锁
+// sweep removes long idle limiters once a minute, or once a second while the table is full; the caller must hold the lock
+func (rl *rateLimiter) sweep(now time.Time) {
+	interval := time.Minute
+	if len(rl.clients) >= maxClients {
+		interval = time.Second
+	}
+	if now.Sub(rl.lastSweep) < interval {
+		return
+	}
+	rl.lastSweep = now
+	for id, l := range rl.clients {
+		if now.Sub(l.lastSeen) > idleLimiterTTL {
+			delete(rl.clients, id)
+		}
+	}
+}
+
+// waitBandwidth 等待所有带宽令牌桶放行 n 个字节
+// waitBandwidth waits until every bandwidth bucket lets n bytes through
+func waitBandwidth(ctx context.Context, limiters []*rate.Limiter, n int) error {
+	for _, l := range limiters {
+		if err := l.WaitN(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxChunk 返回单次读写允许的最大字节数，即最小的桶容量
+// maxChunk returns the most bytes allowed per read or write, i.e. the smallest bucket size
+func maxChunk(limiters []*rate.Limiter, n int) int {
+	for _, l := range limiters {
+		n = min(n, l.Burst())
+	}
+	return n
+}
+
+// throttledWriter 按带宽限制写出响应体
+// throttledWriter writes the response body at the bandwidth limit
+type throttledWriter struct {
+	gin.ResponseWriter
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := maxChunk(w.limiters, len(p))
+		if err := waitBandwidth(w.ctx, w.limiters, n); err != nil {
+			return written, err
+		}
+		m, err := w.ResponseWriter.Write(p[:n])
+		written += m
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (w *throttledWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// throttledReader 按带宽限制读取请求体
+// throttledReader reads the request body at the bandwidth limit
+type throttledReader struct {
+	io.ReadCloser
+	ctx      context.Context
+	limiters []*rate.Limiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p[:maxChunk(r.limiters, len(p))])
+	if n > 0 {
+		if waitErr := waitBandwidth(r.ctx, r.limiters, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
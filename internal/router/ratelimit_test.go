@@ -0,0 +1,130 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// limitedEngine 返回只挂载了限流中间件的引擎，每个请求都返回 204
+// limitedEngine returns an engine with only the rate limiting middleware, answering every request with 204
+func limitedEngine(rl *rateLimiter) *gin.Engine {
+	r := gin.New()
+	r.Use(rl.Handler)
+	r.Any("/", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return r
+}
+
+// send 以 ip 为对端地址发送请求；token 非空时作为 Bearer Token 发送
+// send sends a request from peer address ip; a non-empty token is sent as a Bearer token
+func send(r http.Handler, ip, token string) int {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRateLimitPerIPAndKey(t *testing.T) {
+	ts := newTestServer(t)
+	ts.srv.Config.RateLimitIPRequests = 1
+	ts.srv.Config.RateLimitKeyRequests = 1
+	rl := newRateLimiter(ts.srv.Config, ts.srv.APIKeys)
+	r := limitedEngine(rl)
+	key := ts.seedKey(models.ScopeDownload)
+
+	// 每个 IP 和每个密钥各自只能突发一个请求
+	// Each IP and each key may only burst one request
+	if code := send(r, "192.0.2.1", key); code != http.StatusNoContent {
+		t.Fatalf("first request = %d", code)
+	}
+	if code := send(r, "192.0.2.1", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("same IP = %d, want 429", code)
+	}
+	if code := send(r, "192.0.2.2", key); code != http.StatusTooManyRequests {
+		t.Fatalf("same key = %d, want 429", code)
+	}
+	// 被密钥拒绝的请求归还了 IP 的令牌
+	// A request the key rejected hands the IP's token back
+	if code := send(r, "192.0.2.2", ""); code != http.StatusNoContent {
+		t.Fatalf("new IP after key rejection = %d", code)
+	}
+}
+
+func TestRateLimitDoesNotCacheUnknownKeys(t *testing.T) {
+	ts := newTestServer(t)
+	ts.srv.Config.RateLimitKeyRequests = 1
+	rl := newRateLimiter(ts.srv.Config, ts.srv.APIKeys)
+	r := limitedEngine(rl)
+
+	for i := range 50 {
+		send(r, "192.0.2.1", fmt.Sprintf("gofi_unknown_%d", i))
+	}
+	for id := range rl.clients {
+		if strings.HasPrefix(id, "key:") {
+			t.Fatalf("unknown key cached as %s", id)
+		}
+	}
+}
+
+func TestRateLimitIgnoresUnusableKeys(t *testing.T) {
+	ts := newTestServer(t)
+	ts.srv.Config.RateLimitIPRequests = 1
+	rl := newRateLimiter(ts.srv.Config, ts.srv.APIKeys)
+	r := limitedEngine(rl)
+
+	// 已禁用或已过期的密钥带有的宽松限额不起作用，请求只按 IP 限流，也不会被缓存
+	// The generous limits a disabled or expired key carries do not apply; its requests are only limited by IP and it is not cached
+	generous := func(k *models.ApiKey) {
+		requests := 1000.0
+		k.RateLimitRequests = &requests
+	}
+	disabled := ts.seedKeyWith(func(k *models.ApiKey) { generous(k); k.IsEnabled = false }, models.ScopeDownload)
+	expired := ts.seedKeyWith(func(k *models.ApiKey) { generous(k); k.ExpiresAt = past() }, models.ScopeDownload)
+	for i, key := range []string{disabled, expired} {
+		ip := fmt.Sprintf("192.0.2.%d", i+1)
+		if code := send(r, ip, key); code != http.StatusNoContent {
+			t.Fatalf("first request = %d", code)
+		}
+		if code := send(r, ip, key); code != http.StatusTooManyRequests {
+			t.Fatalf("second request = %d, want 429", code)
+		}
+	}
+	for id := range rl.clients {
+		if strings.HasPrefix(id, "key:") {
+			t.Fatalf("unusable key cached as %s", id)
+		}
+	}
+}
+
+func TestRateLimitTableIsBounded(t *testing.T) {
+	ts := newTestServer(t)
+	ts.srv.Config.RateLimitIPRequests = 1
+	rl := newRateLimiter(ts.srv.Config, ts.srv.APIKeys)
+	r := limitedEngine(rl)
+
+	// 填满限流表，之后的新 IP 共用溢出令牌桶
+	// Fill the table; new IPs then share the overflow buckets
+	now := time.Now()
+	for i := range maxClients {
+		rl.clients[fmt.Sprintf("ip:filler-%d", i)] = &clientLimiter{lastSeen: now}
+	}
+	if code := send(r, "192.0.2.1", ""); code != http.StatusNoContent {
+		t.Fatalf("first overflow IP = %d", code)
+	}
+	if code := send(r, "192.0.2.2", ""); code != http.StatusTooManyRequests {
+		t.Fatalf("second overflow IP = %d, want 429", code)
+	}
+	if len(rl.clients) != maxClients {
+		t.Fatalf("table holds %d clients, want %d", len(rl.clients), maxClients)
+	}
+}
@@ -1,9 +1,10 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/audit"
 	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -12,38 +13,72 @@ import (
 	_ "github.com/ShinoharaHaruna/GoFi/cmd/docs" // docs is generated by Swag CLI
 )
 
-// SetupRouter 配置并返回一个 Gin 引擎
-// SetupRouter configures and returns a Gin engine
-func SetupRouter(cfg *config.Config) *gin.Engine {
+// SetupRouter 配置并返回一个将请求交给 s 处理的 Gin 引擎
+// SetupRouter configures and returns a Gin engine that hands requests to s
+func SetupRouter(s *handlers.Server) (*gin.Engine, error) {
 	r := gin.Default()
 
-	// 将配置注入到上下文中，以便处理程序可以访问
-	// Inject the config into the context so handlers can access it
-	r.Use(func(c *gin.Context) {
-		c.Set("config", cfg)
-		c.Next()
-	})
+	// 只信任配置的代理转发的客户端 IP；IP 绑定、按 IP 限流和审计记录都依赖它
+	// Only trust client IPs forwarded by the configured proxies; IP binding, per-IP rate limits and audit records depend on it
+	if err := r.SetTrustedProxies(s.Config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	// 健康检查不受限流影响，因此在注册限流中间件之前注册
+	// Health checks are exempt from rate limiting, so they are registered before the rate limiting middleware
+	r.GET("/health", handlers.HealthCheck)
+
+	// 按 API Key 和客户端 IP 限制请求数和带宽
+	// Limit requests and bandwidth per API key and per client IP
+	r.Use(newRateLimiter(s.Config, s.APIKeys).Handler)
+
+	// 记录被标记为需要审计的请求
+	// Record the requests marked for auditing
+	r.Use(s.Audit.Handler)
 
 	// API 端点
 	// API Endpoints
 	// 不带 token 的路由（用于 Bearer token 或查询参数）
 	// Routes without token in path (for Bearer token or query param)
-	r.GET("/health", handlers.HealthCheck)
 	r.GET("/uuid", handlers.GenerateUUID)
 
 	// 不带 token 的路由（用于 Bearer token 或查询参数）
 	// Routes without token in path (for Bearer token or query param)
-	r.POST("/upload", handlers.UploadFile)
-	r.POST("/shorten", handlers.CreateShortLink)
-	r.DELETE("/shorten/:shortcode", handlers.DisableShortLink)
-	r.POST("/shorten/:shortcode/enable", handlers.EnableShortLink)
-	r.POST("/api-keys", handlers.CreateAPIKey)
-	r.DELETE("/api-keys/:key", handlers.DisableAPIKey)
-	r.POST("/api-keys/:key/enable", handlers.EnableAPIKey)
+	r.POST("/upload", audit.Action(audit.ActionUpload), s.UploadFile)
+	r.POST("/shorten", audit.Action(audit.ActionShortLinkCreate), s.CreateShortLink)
+	r.DELETE("/shorten/:shortcode", audit.Action(audit.ActionShortLinkDisable), s.DisableShortLink)
+	r.POST("/shorten/:shortcode/enable", audit.Action(audit.ActionShortLinkEnable), s.EnableShortLink)
+	r.GET("/api-keys", s.ListAPIKeys)
+	r.POST("/api-keys", audit.Action(audit.ActionAPIKeyCreate), s.CreateAPIKey)
+	r.DELETE("/api-keys/:id", audit.Action(audit.ActionAPIKeyDisable), s.DisableAPIKey)
+	r.POST("/api-keys/:id/enable", audit.Action(audit.ActionAPIKeyEnable), s.EnableAPIKey)
+
+	// 管理 API
+	// Management API
+	r.GET("/api/files", s.ListFiles)
+	r.DELETE("/api/files/:name", audit.Action(audit.ActionDelete), s.DeleteFile)
+	r.POST("/api/signed-urls", audit.Action(audit.ActionSignedURLCreate), s.CreateSignedURL)
+	r.GET("/api/shortlinks", s.ListShortLinks)
+	r.GET("/api/shortlinks/:code", s.GetShortLink)
+	r.PATCH("/api/shortlinks/:code", audit.Action(audit.ActionShortLinkUpdate), s.UpdateShortLink)
+	r.GET("/api/shortlinks/:code/stats", s.GetShortLinkStats)
+	r.GET("/api/usage", s.GetUsage)
+	r.GET("/api/audit", s.ListAuditLogs)
+
+	// tus 可续传上传端点
+	// tus resumable upload endpoints
+	uploads := r.Group("/uploads", handlers.TusResumable)
+	uploads.OPTIONS("", s.TusOptions)
+	uploads.POST("", s.CreateTusUpload)
+	uploads.HEAD("/:id", s.GetTusUploadOffset)
+	uploads.PATCH("/:id", s.PatchTusUpload)
+	uploads.DELETE("/:id", audit.Action(audit.ActionUploadTerminate), s.TerminateTusUpload)
 
 	// 短链接下载端点（这个不需要 token）
 	// Short link download endpoint (this one doesn't need a token itself)
-	r.GET("/s/:shortcode", handlers.DownloadFileFromShortLink)
+	r.GET("/s/:shortcode", s.DownloadFileFromShortLink)
+	r.HEAD("/s/:shortcode", s.DownloadFileFromShortLink)
+	r.POST("/s/:shortcode", s.UnlockShortLink)
 
 	// Swagger 端点
 	// Swagger endpoint
@@ -54,7 +89,27 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 
 	// 文件下载路由必须放在最后，以避免路径冲突
 	// The file download route must be last to avoid path conflicts
-	r.GET("/:filename", handlers.DownloadFile)
+	r.GET("/:filename", s.DownloadFile)
+
+	return r, nil
+}
 
-	return r
+// NewHTTPServer 创建监听配置端口、将请求交给 s 处理的 HTTP 服务器，并应用配置中的超时
+// NewHTTPServer creates the HTTP server that listens on the configured port and hands requests to s, applying the configured timeouts
+func NewHTTPServer(s *handlers.Server) (*http.Server, error) {
+	cfg := s.Config
+	r, err := SetupRouter(s)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Server{
+		Addr: fmt.Sprintf(":%s", cfg.GoFiPort),
+		Handler: transferDeadlines{
+			next:         r,
+			readTimeout:  cfg.HTTPReadTimeout,
+			writeTimeout: cfg.HTTPWriteTimeout,
+		},
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+	}, nil
 }
@@ -0,0 +1,225 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+	"github.com/ShinoharaHaruna/GoFi/internal/utility"
+)
+
+func TestShortLinkLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "doc.txt", "content")
+	shorten := ts.seedKey(models.ScopeShorten)
+
+	// 创建需要 shorten 权限
+	// Creating requires the shorten scope
+	rec := ts.sendJSON(http.MethodPost, "/shorten", ts.seedKey(models.ScopeUpload), handlers.CreateShortLinkRequest{Filename: "doc.txt"})
+	expectStatus(t, rec, http.StatusUnauthorized)
+	code := ts.shorten(shorten, handlers.CreateShortLinkRequest{Filename: "doc.txt"})
+
+	expectBody(t, ts.get("/s/"+code, ""), "content")
+
+	var link handlers.ShortLinkResponse
+	decodeJSON(t, ts.get("/api/shortlinks/"+code, shorten), &link)
+	if link.DownloadCount != 1 || !link.IsEnabled || link.File == nil || link.File.Name != "doc.txt" {
+		t.Fatalf("short link = %+v", link)
+	}
+
+	// 禁用和启用同样需要 shorten 权限
+	// Disabling and enabling need the shorten scope as well
+	expectStatus(t, ts.do(http.MethodDelete, "/shorten/"+code, ts.seedKey(models.ScopeDownload), nil, nil), http.StatusUnauthorized)
+	expectStatus(t, ts.do(http.MethodDelete, "/shorten/"+code, shorten, nil, nil), http.StatusOK)
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusNotFound)
+
+	expectStatus(t, ts.do(http.MethodPost, "/shorten/"+code+"/enable", "", nil, nil), http.StatusUnauthorized)
+	expectStatus(t, ts.do(http.MethodPost, "/shorten/"+code+"/enable", shorten, nil, nil), http.StatusOK)
+	expectBody(t, ts.get("/s/"+code, ""), "content")
+
+	// 删除文件后短链接被禁用
+	// Deleting the file disables the short link
+	expectStatus(t, ts.do(http.MethodDelete, "/api/files/doc.txt?visibility=public", ts.seedKey(models.ScopeDelete), nil, nil), http.StatusOK)
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusNotFound)
+}
+
+func TestShortLinkToUnknownFile(t *testing.T) {
+	ts := newTestServer(t)
+	shorten := ts.seedKey(models.ScopeShorten)
+
+	rec := ts.sendJSON(http.MethodPost, "/shorten", shorten, handlers.CreateShortLinkRequest{Filename: "missing.txt"})
+	expectStatus(t, rec, http.StatusNotFound)
+	expectStatus(t, ts.get("/s/doesnotexist", ""), http.StatusNotFound)
+}
+
+func TestPrivateShortLinkRequiresDownloadScope(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "secret.txt", "secret")
+	code := ts.shorten(ts.seedKey(models.ScopeShorten), handlers.CreateShortLinkRequest{Filename: "secret.txt"})
+
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusUnauthorized)
+	expectStatus(t, ts.get("/s/"+code, ts.seedKey(models.ScopeShorten)), http.StatusUnauthorized)
+	expectBody(t, ts.get("/s/"+code, ts.seedKey(models.ScopeDownload)), "secret")
+}
+
+func TestShortLinkDownloadLimit(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "once.txt", "once")
+	maxDownloads := int64(1)
+	code := ts.shorten(ts.seedKey(models.ScopeShorten), handlers.CreateShortLinkRequest{Filename: "once.txt", MaxDownloads: &maxDownloads})
+
+	// HEAD 请求不占用下载次数
+	// HEAD requests claim no download
+	expectStatus(t, ts.do(http.MethodHead, "/s/"+code, "", nil, nil), http.StatusOK)
+
+	expectBody(t, ts.get("/s/"+code, ""), "once")
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusGone)
+	expectStatus(t, ts.do(http.MethodHead, "/s/"+code, "", nil, nil), http.StatusGone)
+}
+
+func TestShortLinkExpiryIgnoresTimeZones(t *testing.T) {
+	// SQLite 按字符串比较时间，因此时区不同的时间必须先统一为 UTC
+	// SQLite compares times as strings, so times in different zones must be brought to UTC first
+	local := time.Local
+	time.Local = time.FixedZone("UTC+9", 9*60*60)
+	t.Cleanup(func() { time.Local = local })
+
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "doc.txt", "content")
+	expiresAt := time.Now().Add(30 * time.Minute).In(time.FixedZone("UTC-5", -5*60*60))
+	code := ts.shorten(ts.seedKey(models.ScopeShorten), handlers.CreateShortLinkRequest{Filename: "doc.txt", ExpiresAt: &expiresAt})
+
+	expectBody(t, ts.get("/s/"+code, ""), "content")
+}
+
+func TestShortLinkFailedDownloadKeepsLinkUsable(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "once.txt", "once")
+	maxDownloads := int64(1)
+	code := ts.shorten(ts.seedKey(models.ScopeShorten), handlers.CreateShortLinkRequest{Filename: "once.txt", MaxDownloads: &maxDownloads})
+
+	// 存储中的对象暂时缺失时下载失败，但不会用掉唯一的下载次数
+	// While the stored object is missing the download fails, but it does not use up the only download
+	ctx := context.Background()
+	if err := ts.srv.Storage.Delete(ctx, storage.VisibilityPublic, "once.txt"); err != nil {
+		t.Fatalf("delete object: %v", err)
+	}
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusNotFound)
+
+	if _, err := ts.srv.Storage.Put(ctx, storage.VisibilityPublic, "once.txt", strings.NewReader("once")); err != nil {
+		t.Fatalf("restore object: %v", err)
+	}
+	expectBody(t, ts.get("/s/"+code, ""), "once")
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusGone)
+}
+
+func TestShortLinkPassword(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "locked.txt", "locked")
+	code := ts.shorten(ts.seedKey(models.ScopeShorten), handlers.CreateShortLinkRequest{Filename: "locked.txt", Password: "correct horse"})
+
+	// 密码代替下载 Token
+	// The password replaces the download Token
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusUnauthorized)
+	wrong := http.Header{handlers.ShortLinkPasswordHeader: {"battery staple"}}
+	expectStatus(t, ts.do(http.MethodGet, "/s/"+code, "", nil, wrong), http.StatusUnauthorized)
+	right := http.Header{handlers.ShortLinkPasswordHeader: {"correct horse"}}
+	expectBody(t, ts.do(http.MethodGet, "/s/"+code, "", nil, right), "locked")
+}
+
+func TestShortLinkAlias(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "doc.txt", "content")
+	shorten := ts.seedKey(models.ScopeShorten)
+
+	if code := ts.shorten(shorten, handlers.CreateShortLinkRequest{Filename: "doc.txt", Alias: "my-doc"}); code != "my-doc" {
+		t.Fatalf("short code = %q, want my-doc", code)
+	}
+	expectBody(t, ts.get("/s/my-doc", ""), "content")
+
+	for alias, want := range map[string]int{
+		"my-doc": http.StatusConflict,
+		"upload": http.StatusBadRequest,
+		"../x":   http.StatusBadRequest,
+	} {
+		t.Run(alias, func(t *testing.T) {
+			rec := ts.sendJSON(http.MethodPost, "/shorten", shorten, handlers.CreateShortLinkRequest{Filename: "doc.txt", Alias: alias})
+			expectStatus(t, rec, want)
+		})
+	}
+}
+
+func TestShortLinkPathTraversal(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "secret.txt", "secret")
+	shorten := ts.seedKey(models.ScopeShorten)
+
+	for _, name := range []string{"../private/secret.txt", "private/secret.txt", "..", "/etc/passwd"} {
+		t.Run(name, func(t *testing.T) {
+			rec := ts.sendJSON(http.MethodPost, "/shorten", shorten, handlers.CreateShortLinkRequest{Filename: name})
+			expectStatus(t, rec, http.StatusBadRequest)
+		})
+	}
+}
+
+func TestBackfillLinksOnlyMarkedLegacyShortLinks(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPublic, "a.txt", "a")
+	for _, link := range []models.ShortLink{
+		{ShortCode: "legacy", OriginalFilename: "a.txt", LegacyUnlinked: true},
+		{ShortCode: "missing", OriginalFilename: "b.txt", LegacyUnlinked: true},
+		{ShortCode: "orphan", OriginalFilename: "a.txt"},
+	} {
+		// 创建时 false 会被列默认值 true 取代，需要单独更新
+		// On create a false is replaced by the column default of true, so it takes a separate update
+		if err := ts.srv.ShortLinks.Create(&link); err != nil {
+			t.Fatalf("create link: %v", err)
+		}
+		if err := ts.srv.ShortLinks.Update(&link, map[string]any{"is_private": false}); err != nil {
+			t.Fatalf("update link: %v", err)
+		}
+	}
+	backfill := func() {
+		t.Helper()
+		if err := utility.BackfillFileRecords(context.Background(), ts.srv.Storage, ts.srv.Files, ts.srv.ShortLinks); err != nil {
+			t.Fatalf("backfill: %v", err)
+		}
+	}
+
+	// 只有标记的旧短链接被关联，已删除文件留下的短链接保持失效
+	// Only the marked legacy link is linked; the link a deleted file left behind stays dead
+	backfill()
+	expectBody(t, ts.get("/s/legacy", ""), "a")
+	expectStatus(t, ts.get("/s/orphan", ""), http.StatusNotFound)
+
+	// 标记只使用一次：之后出现的同名文件不会被关联
+	// The mark is used once: a file with the same name that shows up later is not linked
+	if _, err := ts.srv.Storage.Put(context.Background(), storage.VisibilityPublic, "b.txt", strings.NewReader("b")); err != nil {
+		t.Fatalf("put object: %v", err)
+	}
+	backfill()
+	expectStatus(t, ts.get("/s/missing", ""), http.StatusNotFound)
+}
+
+func TestPublishingPrivateFileRequiresDownloadScope(t *testing.T) {
+	ts := newTestServer(t)
+	ts.mustUpload(ts.seedKey(models.ScopeUpload), storage.VisibilityPrivate, "secret.txt", "secret")
+	shorten := ts.seedKey(models.ScopeShorten)
+	code := ts.shorten(shorten, handlers.CreateShortLinkRequest{Filename: "secret.txt"})
+	public := string(storage.VisibilityPublic)
+	req := handlers.UpdateShortLinkRequest{Visibility: &public}
+
+	// 只能创建短链接的密钥不能借此公开私有文件
+	// A key that can only shorten links cannot use one to publish a private file
+	expectStatus(t, ts.sendJSON(http.MethodPatch, "/api/shortlinks/"+code, shorten, req), http.StatusForbidden)
+	expectStatus(t, ts.get("/s/"+code, ""), http.StatusUnauthorized)
+
+	both := ts.seedKey(models.ScopeShorten, models.ScopeDownload)
+	expectStatus(t, ts.sendJSON(http.MethodPatch, "/api/shortlinks/"+code, both, req), http.StatusOK)
+	expectBody(t, ts.get("/s/"+code, ""), "secret")
+}
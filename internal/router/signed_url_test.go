@@ -0,0 +1,56 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+	"github.com/ShinoharaHaruna/GoFi/internal/handlers"
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+// httptest 请求的对端地址 / Peer address of httptest requests
+const testPeerIP = "192.0.2.1"
+
+// signIPBound 上传私有文件并签发绑定到 clientIP 的 URL
+// signIPBound uploads a private file and issues a URL bound to clientIP
+func signIPBound(ts *testServer, clientIP string) string {
+	ts.t.Helper()
+	key := ts.seedKey(models.ScopeUpload, models.ScopeDownload)
+	ts.mustUpload(key, storage.VisibilityPrivate, "secret.txt", "secret")
+	rec := ts.sendJSON(http.MethodPost, "/api/signed-urls", key, handlers.CreateSignedURLRequest{Filename: "secret.txt", BindIP: true, ClientIP: clientIP})
+	expectStatus(ts.t, rec, http.StatusOK)
+	var resp struct {
+		URLPath string `json:"url_path"`
+	}
+	decodeJSON(ts.t, rec, &resp)
+	return resp.URLPath
+}
+
+func TestSignedURLBoundToIP(t *testing.T) {
+	ts := newTestServer(t)
+	target := signIPBound(ts, testPeerIP)
+
+	expectBody(t, ts.get(target, ""), "secret")
+}
+
+func TestSignedURLRejectsSpoofedForwardedFor(t *testing.T) {
+	ts := newTestServer(t)
+	target := signIPBound(ts, "203.0.113.7")
+
+	// 不信任任何代理时，伪造的转发头不能冒充绑定的 IP
+	// With no trusted proxy a forged forwarding header cannot pose as the bound IP
+	for _, name := range []string{"X-Forwarded-For", "X-Real-IP"} {
+		rec := ts.do(http.MethodGet, target, "", nil, http.Header{name: {"203.0.113.7"}})
+		expectStatus(t, rec, http.StatusForbidden)
+	}
+}
+
+func TestSignedURLHonorsTrustedProxy(t *testing.T) {
+	ts := newTestServer(t, func(cfg *config.Config) { cfg.TrustedProxies = []string{testPeerIP + "/32"} })
+	target := signIPBound(ts, "203.0.113.7")
+
+	rec := ts.do(http.MethodGet, target, "", nil, http.Header{"X-Forwarded-For": {"203.0.113.7"}})
+	expectBody(t, rec, "secret")
+}
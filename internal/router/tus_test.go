@@ -0,0 +1,102 @@
+package router
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+// tusHeader 返回带有协议版本和 extra 中各项的请求头
+// tusHeader returns request headers carrying the protocol version and the entries of extra
+func tusHeader(extra ...string) http.Header {
+	header := http.Header{"Tus-Resumable": {"1.0.0"}}
+	for i := 0; i+1 < len(extra); i += 2 {
+		header.Set(extra[i], extra[i+1])
+	}
+	return header
+}
+
+// createTusUpload 创建 length 字节的公开上传，返回其路径
+// createTusUpload creates a public upload of length bytes and returns its path
+func (ts *testServer) createTusUpload(token, filename string, length int) string {
+	ts.t.Helper()
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte(filename)) + ",visibility " + base64.StdEncoding.EncodeToString([]byte("public"))
+	header := tusHeader("Upload-Length", strconv.Itoa(length), "Upload-Metadata", metadata)
+	rec := ts.do(http.MethodPost, "/uploads", token, nil, header)
+	expectStatus(ts.t, rec, http.StatusCreated)
+	return rec.Header().Get("Location")
+}
+
+// patchTus 从 offset 处追加 chunk
+// patchTus appends chunk at offset
+func (ts *testServer) patchTus(location, token string, offset int, chunk string) *httptest.ResponseRecorder {
+	ts.t.Helper()
+	header := tusHeader("Content-Type", "application/offset+octet-stream", "Upload-Offset", strconv.Itoa(offset))
+	return ts.do(http.MethodPatch, location, token, strings.NewReader(chunk), header)
+}
+
+func TestTusUploadBelongsToCreator(t *testing.T) {
+	ts := newTestServer(t)
+	owner := ts.seedKey(models.ScopeUpload)
+	other := ts.seedKey(models.ScopeUpload)
+	location := ts.createTusUpload(owner, "resumable.txt", 10)
+
+	expectStatus(t, ts.patchTus(location, owner, 0, "hello"), http.StatusNoContent)
+
+	// 其他密钥看不到、也不能续传或终止这个上传
+	// Other keys can neither see, continue nor terminate the upload
+	expectStatus(t, ts.do(http.MethodHead, location, other, nil, tusHeader()), http.StatusNotFound)
+	expectStatus(t, ts.patchTus(location, other, 5, "world"), http.StatusNotFound)
+	expectStatus(t, ts.do(http.MethodDelete, location, other, nil, tusHeader()), http.StatusNotFound)
+
+	expectStatus(t, ts.patchTus(location, owner, 5, "world"), http.StatusNoContent)
+	expectBody(t, ts.get("/resumable.txt", ""), "helloworld")
+}
+
+func TestTusCompletionRechecksQuota(t *testing.T) {
+	ts := newTestServer(t)
+	quota := int64(1000)
+	key := ts.seedKeyWith(func(k *models.ApiKey) { k.StorageQuota = &quota }, models.ScopeUpload, models.ScopeDelete)
+	location := ts.createTusUpload(key, "big.txt", 600)
+
+	// 创建上传后，其他上传用掉了配额
+	// After the upload was created, another upload used up the quota
+	ts.mustUpload(key, storage.VisibilityPublic, "small.txt", strings.Repeat("s", 500))
+	expectStatus(t, ts.patchTus(location, key, 0, strings.Repeat("b", 600)), http.StatusRequestEntityTooLarge)
+	expectStatus(t, ts.get("/big.txt", ""), http.StatusNotFound)
+
+	// 上传被保留，腾出空间后可以用空的 PATCH 完成
+	// The upload is kept and completes with an empty PATCH once space is freed
+	rec := ts.do(http.MethodHead, location, key, nil, tusHeader())
+	expectStatus(t, rec, http.StatusOK)
+	if offset := rec.Header().Get("Upload-Offset"); offset != "600" {
+		t.Fatalf("Upload-Offset = %s, want 600", offset)
+	}
+	expectStatus(t, ts.do(http.MethodDelete, "/api/files/small.txt?visibility=public", key, nil, nil), http.StatusOK)
+	expectStatus(t, ts.patchTus(location, key, 600, ""), http.StatusNoContent)
+	expectBody(t, ts.get("/big.txt", ""), strings.Repeat("b", 600))
+}
+
+func TestTusExpireRemovesStaleUploads(t *testing.T) {
+	ts := newTestServer(t)
+	key := ts.seedKey(models.ScopeUpload)
+	stale := ts.createTusUpload(key, "stale.txt", 10)
+	expectStatus(t, ts.patchTus(stale, key, 0, "hello"), http.StatusNoContent)
+
+	removed, err := ts.srv.Tus.Expire(time.Hour, time.Now())
+	if err != nil || removed != 0 {
+		t.Fatalf("fresh uploads: removed %d, err %v", removed, err)
+	}
+	removed, err = ts.srv.Tus.Expire(time.Hour, time.Now().Add(2*time.Hour))
+	if err != nil || removed != 1 {
+		t.Fatalf("stale uploads: removed %d, err %v", removed, err)
+	}
+	expectStatus(t, ts.do(http.MethodHead, stale, key, nil, tusHeader()), http.StatusNotFound)
+}
@@ -0,0 +1,205 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempPrefix 是写入过程中临时文件的前缀，List 会忽略它们
+// tempPrefix is the prefix of in-flight temporary files; List skips them
+const tempPrefix = ".gofi-tmp-"
+
+// LocalFS 将对象保存在本地目录 <baseDir>/<visibility>/<name> 下
+// LocalFS stores objects on the local disk under <baseDir>/<visibility>/<name>
+type LocalFS struct {
+	baseDir string
+}
+
+// NewLocalFS 创建一个以 baseDir 为根目录的本地存储后端
+// NewLocalFS creates a local storage backend rooted at baseDir
+func NewLocalFS(baseDir string) *LocalFS {
+	return &LocalFS{baseDir: baseDir}
+}
+
+// Put 先写入临时文件再原子重命名，避免读者看到写了一半的文件
+// Put writes to a temporary file and renames it atomically so readers never see a partial file
+func (l *LocalFS) Put(ctx context.Context, visibility Visibility, name string, r io.Reader) (ObjectInfo, error) {
+	destPath, err := l.path(visibility, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	dir := filepath.Dir(destPath)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	// 出错时清理临时文件；成功重命名后 Remove 会无害地失败
+	// Clean up the temporary file on failure; after a successful rename Remove fails harmlessly
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, contextReader{ctx: ctx, r: r}); err != nil {
+		tmp.Close()
+		return ObjectInfo{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := os.Rename(tmp.Name(), destPath); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	return l.Stat(ctx, visibility, name)
+}
+
+// Get 打开本地文件
+// Get opens the local file
+func (l *LocalFS) Get(ctx context.Context, visibility Visibility, name string) (Object, ObjectInfo, error) {
+	fullPath, err := l.path(visibility, name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return nil, ObjectInfo{}, mapErr(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, ObjectInfo{}, mapErr(err)
+	}
+	if fi.IsDir() {
+		f.Close()
+		return nil, ObjectInfo{}, ErrNotFound
+	}
+
+	return f, toObjectInfo(visibility, fi), nil
+}
+
+// Stat 返回本地文件的元数据
+// Stat returns the metadata of the local file
+func (l *LocalFS) Stat(ctx context.Context, visibility Visibility, name string) (ObjectInfo, error) {
+	fullPath, err := l.path(visibility, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	fi, err := os.Stat(fullPath)
+	if err != nil {
+		return ObjectInfo{}, mapErr(err)
+	}
+	if fi.IsDir() {
+		return ObjectInfo{}, ErrNotFound
+	}
+	return toObjectInfo(visibility, fi), nil
+}
+
+// Delete 删除本地文件
+// Delete removes the local file
+func (l *LocalFS) Delete(ctx context.Context, visibility Visibility, name string) error {
+	fullPath, err := l.path(visibility, name)
+	if err != nil {
+		return err
+	}
+	return mapErr(os.Remove(fullPath))
+}
+
+// List 列出可见性目录下的普通文件，目录不存在时返回空列表
+// List lists regular files in the visibility directory; a missing directory yields an empty list
+func (l *LocalFS) List(ctx context.Context, visibility Visibility) ([]ObjectInfo, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return nil, ErrInvalidVisibility
+	}
+
+	entries, err := os.ReadDir(filepath.Join(l.baseDir, string(visibility)))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ObjectInfo, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		fi, err := entry.Info()
+		if err != nil {
+			continue // 文件在遍历期间被删除 / File was removed while listing
+		}
+		infos = append(infos, toObjectInfo(visibility, fi))
+	}
+	return infos, nil
+}
+
+// path 校验参数并构建对象在磁盘上的路径
+// path validates the arguments and builds the on-disk path of an object
+func (l *LocalFS) path(visibility Visibility, name string) (string, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return "", ErrInvalidVisibility
+	}
+	if !ValidName(name) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(l.baseDir, string(visibility), name), nil
+}
+
+// ValidName 检查对象名称是否为单个安全的路径段
+// ValidName reports whether name is a single, safe path segment
+func ValidName(name string) bool {
+	if name == "" || name == "." || name == ".." {
+		return false
+	}
+	if strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+		return false
+	}
+	return !strings.HasPrefix(name, tempPrefix)
+}
+
+// toObjectInfo 将 fs.FileInfo 转换为 ObjectInfo
+// toObjectInfo converts an fs.FileInfo into an ObjectInfo
+func toObjectInfo(visibility Visibility, fi fs.FileInfo) ObjectInfo {
+	return ObjectInfo{
+		Name:       fi.Name(),
+		Visibility: visibility,
+		Size:       fi.Size(),
+		ModTime:    fi.ModTime(),
+	}
+}
+
+// mapErr 将“不存在”类错误统一为 ErrNotFound
+// mapErr normalises "does not exist" errors into ErrNotFound
+func mapErr(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// contextReader 在上下文取消后停止读取
+// contextReader stops reading once the context is cancelled
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
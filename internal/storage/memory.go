@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Memory 将对象保存在进程内存中，重启后内容丢失；用于测试
+// Memory keeps objects in process memory, so they are gone after a restart; it is meant for tests
+type Memory struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// memoryObject 是 Memory 中一个对象的内容和元数据
+// memoryObject is the content and metadata of one object in Memory
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// NewMemory 创建一个空的内存存储后端
+// NewMemory creates an empty in-memory storage backend
+func NewMemory() *Memory {
+	return &Memory{objects: make(map[string]memoryObject)}
+}
+
+// Put 读完全部内容后才替换对象，读者不会看到写了一半的内容
+// Put only replaces the object once all content is read, so readers never see partial content
+func (m *Memory) Put(ctx context.Context, visibility Visibility, name string, r io.Reader) (ObjectInfo, error) {
+	key, err := m.key(visibility, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	data, err := io.ReadAll(contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	info := ObjectInfo{Name: name, Visibility: visibility, Size: int64(len(data)), ModTime: time.Now()}
+
+	m.mu.Lock()
+	m.objects[key] = memoryObject{data: data, info: info}
+	m.mu.Unlock()
+	return info, nil
+}
+
+// Get 返回对象内容的读取器；Put 替换而不修改内容，因此不影响已打开的对象
+// Get returns a reader over the object content; Put replaces rather than modifies content, so objects already opened are unaffected
+func (m *Memory) Get(ctx context.Context, visibility Visibility, name string) (Object, ObjectInfo, error) {
+	obj, err := m.find(visibility, name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return nopCloser{bytes.NewReader(obj.data)}, obj.info, nil
+}
+
+// Stat 返回对象的元数据
+// Stat returns the metadata of the object
+func (m *Memory) Stat(ctx context.Context, visibility Visibility, name string) (ObjectInfo, error) {
+	obj, err := m.find(visibility, name)
+	return obj.info, err
+}
+
+// Delete 删除对象
+// Delete removes the object
+func (m *Memory) Delete(ctx context.Context, visibility Visibility, name string) error {
+	key, err := m.key(visibility, name)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+// List 按名称顺序列出可见性区域内的对象
+// List lists the objects within a visibility area in name order
+func (m *Memory) List(ctx context.Context, visibility Visibility) ([]ObjectInfo, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return nil, ErrInvalidVisibility
+	}
+
+	m.mu.RLock()
+	var infos []ObjectInfo
+	for _, obj := range m.objects {
+		if obj.info.Visibility == visibility {
+			infos = append(infos, obj.info)
+		}
+	}
+	m.mu.RUnlock()
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos, nil
+}
+
+// find 返回 visibility/name 处的对象
+// find returns the object at visibility/name
+func (m *Memory) find(visibility Visibility, name string) (memoryObject, error) {
+	key, err := m.key(visibility, name)
+	if err != nil {
+		return memoryObject{}, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	obj, ok := m.objects[key]
+	if !ok {
+		return memoryObject{}, ErrNotFound
+	}
+	return obj, nil
+}
+
+// key 校验参数并构建对象在表中的键
+// key validates the arguments and builds the table key of an object
+func (m *Memory) key(visibility Visibility, name string) (string, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return "", ErrInvalidVisibility
+	}
+	if !ValidName(name) {
+		return "", ErrInvalidName
+	}
+	return string(visibility) + "/" + name, nil
+}
+
+// nopCloser 为可定位的读取器加上无操作的 Close
+// nopCloser adds a no-op Close to a seekable reader
+type nopCloser struct {
+	io.ReadSeeker
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// s3PartSize 是分片上传的分片大小，未知长度的上传会按此大小缓冲
+// s3PartSize is the multipart chunk size; uploads of unknown length are buffered in chunks of this size
+const s3PartSize = 16 << 20
+
+// S3Config 描述连接 S3 兼容对象存储所需的参数
+// S3Config holds what is needed to reach an S3-compatible object store
+type S3Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+	UseSSL          bool
+}
+
+// S3 将对象保存在 S3 兼容存储桶中，键为 <prefix>/<visibility>/<name>
+// S3 stores objects in an S3-compatible bucket under the key <prefix>/<visibility>/<name>
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 创建 S3 存储后端并确认存储桶可访问
+// NewS3 creates an S3 storage backend and verifies that the bucket is reachable
+func NewS3(ctx context.Context, cfg S3Config) (*S3, error) {
+	if cfg.Endpoint == "" || cfg.Bucket == "" {
+		return nil, errors.New("storage: S3_ENDPOINT and S3_BUCKET are required for the s3 backend")
+	}
+
+	// 允许带协议的端点，例如 http://localhost:9000
+	// Accept endpoints with a scheme, e.g. http://localhost:9000
+	endpoint, secure := cfg.Endpoint, cfg.UseSSL
+	if u, err := url.Parse(cfg.Endpoint); err == nil && u.Host != "" {
+		endpoint, secure = u.Host, u.Scheme == "https"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: secure,
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := client.BucketExists(ctx, cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("storage: failed to reach bucket %q: %w", cfg.Bucket, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("storage: bucket %q does not exist", cfg.Bucket)
+	}
+
+	return &S3{
+		client: client,
+		bucket: cfg.Bucket,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+	}, nil
+}
+
+// Put 以流的方式上传对象
+// Put streams the object into the bucket
+func (s *S3) Put(ctx context.Context, visibility Visibility, name string, r io.Reader) (ObjectInfo, error) {
+	key, err := s.key(visibility, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	info, err := s.client.PutObject(ctx, s.bucket, key, r, -1, minio.PutObjectOptions{
+		PartSize:    s3PartSize,
+		ContentType: mime.TypeByExtension(path.Ext(name)),
+	})
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	modTime := info.LastModified
+	if modTime.IsZero() {
+		modTime = time.Now()
+	}
+	return ObjectInfo{Name: name, Visibility: visibility, Size: info.Size, ModTime: modTime}, nil
+}
+
+// Get 打开对象以供读取，返回的对象支持 Seek，因此可以服务 Range 请求
+// Get opens the object for reading; the returned object supports Seek so Range requests work
+func (s *S3) Get(ctx context.Context, visibility Visibility, name string) (Object, ObjectInfo, error) {
+	key, err := s.key(visibility, name)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, ObjectInfo{}, mapS3Err(err)
+	}
+	st, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		return nil, ObjectInfo{}, mapS3Err(err)
+	}
+
+	return obj, ObjectInfo{Name: name, Visibility: visibility, Size: st.Size, ModTime: st.LastModified}, nil
+}
+
+// Stat 返回对象的元数据
+// Stat returns the object's metadata
+func (s *S3) Stat(ctx context.Context, visibility Visibility, name string) (ObjectInfo, error) {
+	key, err := s.key(visibility, name)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	st, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil {
+		return ObjectInfo{}, mapS3Err(err)
+	}
+	return ObjectInfo{Name: name, Visibility: visibility, Size: st.Size, ModTime: st.LastModified}, nil
+}
+
+// Delete 删除对象；S3 删除不存在的对象不会报错，因此先 Stat 以保持与 LocalFS 一致
+// Delete removes the object; S3 does not fail on missing keys, so Stat first to match LocalFS
+func (s *S3) Delete(ctx context.Context, visibility Visibility, name string) error {
+	if _, err := s.Stat(ctx, visibility, name); err != nil {
+		return err
+	}
+	key, _ := s.key(visibility, name)
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
+
+// List 列出某个可见性前缀下的对象（不递归）
+// List lists the objects directly under a visibility prefix (non-recursive)
+func (s *S3) List(ctx context.Context, visibility Visibility) ([]ObjectInfo, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return nil, ErrInvalidVisibility
+	}
+
+	listPrefix := path.Join(s.prefix, string(visibility)) + "/"
+	var infos []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: listPrefix}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		name := strings.TrimPrefix(obj.Key, listPrefix)
+		if !ValidName(name) {
+			continue // 跳过“子目录”公共前缀 / Skip "sub-directory" common prefixes
+		}
+		infos = append(infos, ObjectInfo{Name: name, Visibility: visibility, Size: obj.Size, ModTime: obj.LastModified})
+	}
+	return infos, nil
+}
+
+// PresignGet 生成一个限时的直接下载 URL
+// PresignGet generates a time-limited direct download URL
+func (s *S3) PresignGet(ctx context.Context, visibility Visibility, name string, expiry time.Duration) (*url.URL, error) {
+	key, err := s.key(visibility, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.PresignedGetObject(ctx, s.bucket, key, expiry, nil)
+}
+
+// key 校验参数并构建对象键
+// key validates the arguments and builds the object key
+func (s *S3) key(visibility Visibility, name string) (string, error) {
+	if _, ok := ParseVisibility(string(visibility)); !ok {
+		return "", ErrInvalidVisibility
+	}
+	if !ValidName(name) {
+		return "", ErrInvalidName
+	}
+	return path.Join(s.prefix, string(visibility), name), nil
+}
+
+// mapS3Err 将 S3 的“不存在”错误统一为 ErrNotFound
+// mapS3Err normalises S3 "not found" errors into ErrNotFound
+func mapS3Err(err error) error {
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/config"
+)
+
+// Visibility 表示文件所在的访问区域
+// Visibility represents the access area a file lives in
+type Visibility string
+
+const (
+	VisibilityPublic  Visibility = "public"
+	VisibilityPrivate Visibility = "private"
+)
+
+var (
+	// ErrNotFound 表示请求的对象不存在
+	// ErrNotFound indicates that the requested object does not exist
+	ErrNotFound = errors.New("storage: object not found")
+	// ErrInvalidName 表示对象名称不合法（例如包含路径分隔符）
+	// ErrInvalidName indicates that the object name is not acceptable (e.g. contains path separators)
+	ErrInvalidName = errors.New("storage: invalid object name")
+	// ErrInvalidVisibility 表示可见性既不是 public 也不是 private
+	// ErrInvalidVisibility indicates that the visibility is neither public nor private
+	ErrInvalidVisibility = errors.New("storage: invalid visibility")
+)
+
+// ObjectInfo 描述存储中的一个对象
+// ObjectInfo describes a single stored object
+type ObjectInfo struct {
+	Name       string
+	Visibility Visibility
+	Size       int64
+	ModTime    time.Time
+}
+
+// Object 是一个可流式读取、可定位的对象内容
+// Object is a streamable, seekable object body
+type Object interface {
+	io.ReadSeekCloser
+}
+
+// Backend 抽象了文件的存储位置，处理程序只通过该接口访问文件内容
+// Backend abstracts where files are kept; handlers only touch file contents through it
+type Backend interface {
+	// Put 从 r 读取内容并写入 visibility/name，已存在的对象会被替换
+	// Put reads r and stores it as visibility/name, replacing any existing object
+	Put(ctx context.Context, visibility Visibility, name string, r io.Reader) (ObjectInfo, error)
+	// Get 打开 visibility/name 以供读取，调用方负责关闭
+	// Get opens visibility/name for reading; the caller must close it
+	Get(ctx context.Context, visibility Visibility, name string) (Object, ObjectInfo, error)
+	// Stat 返回 visibility/name 的元数据
+	// Stat returns the metadata of visibility/name
+	Stat(ctx context.Context, visibility Visibility, name string) (ObjectInfo, error)
+	// Delete 删除 visibility/name
+	// Delete removes visibility/name
+	Delete(ctx context.Context, visibility Visibility, name string) error
+	// List 列出某个可见性区域内的所有对象
+	// List lists every object within a visibility area
+	List(ctx context.Context, visibility Visibility) ([]ObjectInfo, error)
+}
+
+// Presigner 由能够签发限时直接下载 URL 的后端实现
+// Presigner is implemented by backends that can issue time-limited direct download URLs
+type Presigner interface {
+	PresignGet(ctx context.Context, visibility Visibility, name string, expiry time.Duration) (*url.URL, error)
+}
+
+// 下载模式：由 GoFi 转发内容，或重定向到预签名 URL
+// Download modes: stream the content through GoFi, or redirect to a presigned URL
+const (
+	DownloadModeStream   = "stream"
+	DownloadModeRedirect = "redirect"
+)
+
+// ParseVisibility 将字符串解析为 Visibility
+// ParseVisibility converts a string into a Visibility
+func ParseVisibility(input string) (Visibility, bool) {
+	switch Visibility(input) {
+	case VisibilityPublic, VisibilityPrivate:
+		return Visibility(input), true
+	default:
+		return "", false
+	}
+}
+
+// New 根据配置创建存储后端
+// New creates the storage backend selected by the configuration
+func New(cfg *config.Config) (Backend, error) {
+	switch cfg.StorageBackend {
+	case "", "localfs":
+		return NewLocalFS(cfg.GoFiBaseDir), nil
+	case "s3":
+		if cfg.S3DownloadMode != DownloadModeStream && cfg.S3DownloadMode != DownloadModeRedirect {
+			return nil, fmt.Errorf("storage: unknown S3_DOWNLOAD_MODE %q", cfg.S3DownloadMode)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		return NewS3(ctx, S3Config{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			Prefix:          cfg.S3Prefix,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			UseSSL:          cfg.S3UseSSL,
+		})
+	default:
+		return nil, fmt.Errorf("storage: unknown STORAGE_BACKEND %q", cfg.StorageBackend)
+	}
+}
@@ -0,0 +1,77 @@
+package tus
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"hash"
+	"strings"
+)
+
+// 协议常量（tus 1.0.0 核心协议及 creation、termination、checksum 扩展）
+// Protocol constants (tus 1.0.0 core plus the creation, termination and checksum extensions)
+const (
+	Version            = "1.0.0"
+	Extensions         = "creation,termination,checksum"
+	ChecksumAlgorithms = "sha1,sha256,md5"
+	OffsetContentType  = "application/offset+octet-stream"
+
+	// StatusChecksumMismatch 是 checksum 扩展定义的状态码
+	// StatusChecksumMismatch is the status code defined by the checksum extension
+	StatusChecksumMismatch = 460
+)
+
+// ErrUnsupportedChecksum 表示客户端使用了未支持的校验算法
+// ErrUnsupportedChecksum indicates that the client used a checksum algorithm we do not support
+var ErrUnsupportedChecksum = errors.New("tus: unsupported checksum algorithm")
+
+// ParseMetadata 解析 Upload-Metadata 头："key base64value,key2 base64value2"
+// ParseMetadata parses the Upload-Metadata header: "key base64value,key2 base64value2"
+func ParseMetadata(header string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(header) == "" {
+		return meta, nil
+	}
+
+	for pair := range strings.SplitSeq(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		if key == "" {
+			return nil, errors.New("tus: empty metadata key")
+		}
+		value, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, errors.New("tus: metadata value for " + key + " is not valid base64")
+		}
+		meta[key] = string(value)
+	}
+	return meta, nil
+}
+
+// ParseChecksum 解析 Upload-Checksum 头："<algorithm> <base64 digest>"
+// ParseChecksum parses the Upload-Checksum header: "<algorithm> <base64 digest>"
+func ParseChecksum(header string) (hash.Hash, []byte, error) {
+	algorithm, encoded, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok {
+		return nil, nil, errors.New("tus: malformed Upload-Checksum header")
+	}
+
+	var h hash.Hash
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		h = sha1.New()
+	case "sha256":
+		h = sha256.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return nil, nil, ErrUnsupportedChecksum
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(expected) != h.Size() {
+		return nil, nil, errors.New("tus: malformed checksum digest")
+	}
+	return h, expected, nil
+}
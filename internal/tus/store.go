@@ -0,0 +1,317 @@
+package tus
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
+)
+
+var (
+	// ErrNotFound 表示上传不存在或已被终止
+	// ErrNotFound indicates that the upload does not exist or was terminated
+	ErrNotFound = errors.New("tus: upload not found")
+	// ErrChecksumMismatch 表示分片校验和不匹配，分片已被丢弃
+	// ErrChecksumMismatch indicates that the chunk checksum did not match and the chunk was discarded
+	ErrChecksumMismatch = errors.New("tus: checksum mismatch")
+)
+
+// Upload 描述一个进行中的可续传上传
+// Upload describes an in-progress resumable upload
+type Upload struct {
+	ID          string             `json:"id"`
+	Length      int64              `json:"length"`
+	Offset      int64              `json:"-"` // 由数据文件大小得出 / Derived from the data file size
+	Metadata    string             `json:"metadata"`
+	Filename    string             `json:"filename"`
+	Visibility  storage.Visibility `json:"visibility"`
+	OnConflict  string             `json:"on_conflict"`
+	RenameStyle string             `json:"rename_style"`
+	APIKeyID    uint               `json:"api_key_id"` // 创建上传的 API Key，只有它能继续上传 / API key that created the upload and alone may continue it
+	CreatedAt   time.Time          `json:"created_at"`
+}
+
+// Store 将上传的分片和元数据保存在本地目录中，直到上传完成
+// Store keeps upload chunks and metadata in a local directory until the upload completes
+type Store struct {
+	dir string
+
+	mu    sync.Mutex
+	locks map[string]*uploadLock
+}
+
+// uploadLock 是带引用计数的上传锁
+// uploadLock is a reference-counted upload lock
+type uploadLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewStore 创建一个以 dir 为工作目录的上传存储
+// NewStore creates an upload store working in dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir, locks: make(map[string]*uploadLock)}
+}
+
+// Create 持久化新上传的元数据并创建空数据文件
+// Create persists the metadata of a new upload and creates its empty data file
+func (s *Store) Create(u *Upload) error {
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return err
+	}
+
+	info, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(s.infoPath(u.ID), info, 0o640); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(s.dataPath(u.ID), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		os.Remove(s.infoPath(u.ID))
+		return err
+	}
+	u.Offset = 0
+	return f.Close()
+}
+
+// Get 读取上传的元数据和当前偏移量
+// Get loads an upload's metadata and current offset
+func (s *Store) Get(id string) (*Upload, error) {
+	if !validID(id) {
+		return nil, ErrNotFound
+	}
+
+	raw, err := os.ReadFile(s.infoPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var u Upload
+	if err := json.Unmarshal(raw, &u); err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(s.dataPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	u.Offset = fi.Size()
+	return &u, nil
+}
+
+// WriteChunk 将 r 追加到数据文件末尾（最多 limit 字节），返回新的偏移量。
+// 提供 checksum 时，若校验失败或读取中断，则整个分片会被截断丢弃。
+// WriteChunk appends r (at most limit bytes) to the data file and returns the new offset.
+// When checksum is given, the whole chunk is truncated away if verification fails or the read is interrupted.
+func (s *Store) WriteChunk(u *Upload, r io.Reader, limit int64, checksum hash.Hash, expected []byte) (int64, error) {
+	f, err := os.OpenFile(s.dataPath(u.ID), os.O_WRONLY, 0)
+	if err != nil {
+		return u.Offset, err
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(u.Offset, io.SeekStart); err != nil {
+		return u.Offset, err
+	}
+
+	var w io.Writer = f
+	if checksum != nil {
+		w = io.MultiWriter(f, checksum)
+	}
+
+	n, copyErr := io.Copy(w, io.LimitReader(r, limit))
+	if checksum != nil && (copyErr != nil || !bytes.Equal(checksum.Sum(nil), expected)) {
+		if err := f.Truncate(u.Offset); err != nil {
+			return u.Offset, err
+		}
+		if copyErr != nil {
+			return u.Offset, copyErr
+		}
+		return u.Offset, ErrChecksumMismatch
+	}
+	if err := f.Sync(); err != nil {
+		return u.Offset + n, err
+	}
+	return u.Offset + n, copyErr
+}
+
+// Open 打开已完成上传的数据文件以供读取
+// Open opens the data file of a completed upload for reading
+func (s *Store) Open(id string) (*os.File, error) {
+	f, err := os.Open(s.dataPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+// Terminate 删除上传的数据和元数据
+// Terminate removes an upload's data and metadata
+func (s *Store) Terminate(id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+
+	errInfo := os.Remove(s.infoPath(id))
+	errData := os.Remove(s.dataPath(id))
+
+	if errors.Is(errInfo, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	if errInfo != nil {
+		return errInfo
+	}
+	if errData != nil && !errors.Is(errData, fs.ErrNotExist) {
+		return errData
+	}
+	return nil
+}
+
+// Expire 删除超过 maxAge 未收到数据的上传，返回删除的数量；正在写入的上传会被跳过
+// Expire removes uploads that have received no data for longer than maxAge and returns how many it removed; uploads being written are skipped
+func (s *Store) Expire(maxAge time.Duration, now time.Time) (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), ".info")
+		if !ok || !validID(id) {
+			continue
+		}
+		// 数据文件的修改时间就是最近一次收到数据的时间；正在创建的上传还没有数据文件，此时使用信息文件的时间
+		// The data file's modification time is when data last arrived; an upload being created has no data file yet,
+		// so the info file's time stands in
+		fi, err := os.Stat(s.dataPath(id))
+		if errors.Is(err, fs.ErrNotExist) {
+			fi, err = entry.Info()
+		}
+		if err == nil && now.Sub(fi.ModTime()) <= maxAge {
+			continue
+		}
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return removed, err
+		}
+
+		unlock, ok := s.TryLock(id)
+		if !ok {
+			continue
+		}
+		err = s.Terminate(id)
+		unlock()
+		if errors.Is(err, ErrNotFound) {
+			continue // 已被其他请求删除 / Already removed by another request
+		}
+		if err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// ExpireEvery 在后台立即并随后每隔 interval 调用一次 Expire，直到调用返回的 stop
+// ExpireEvery calls Expire in the background right away and then every interval until the returned stop is called
+func (s *Store) ExpireEvery(interval, maxAge time.Duration) (stop func()) {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := s.Expire(maxAge, time.Now()); err != nil {
+				log.Printf("tus: failed to remove expired uploads: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-quit:
+				return
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+// TryLock 尝试获取上传的独占锁，防止并发 PATCH 交错写入；锁表项带引用计数，无人持有或等待时即被移除，
+// 因此任意 ID 都不会让锁表增长
+// TryLock tries to take an upload's exclusive lock so concurrent PATCHes cannot interleave; lock entries are
+// reference-counted and removed once nobody holds or tries them, so arbitrary IDs cannot grow the table
+func (s *Store) TryLock(id string) (unlock func(), ok bool) {
+	s.mu.Lock()
+	l, exists := s.locks[id]
+	if !exists {
+		l = &uploadLock{}
+		s.locks[id] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	if !l.TryLock() {
+		s.release(id, l)
+		return nil, false
+	}
+	return func() {
+		l.Unlock()
+		s.release(id, l)
+	}, true
+}
+
+// release 减少锁表项的引用计数，归零时将其移除
+// release drops a reference to a lock entry and removes the entry once none are left
+func (s *Store) release(id string, l *uploadLock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if l.refs--; l.refs == 0 {
+		delete(s.locks, id)
+	}
+}
+
+func (s *Store) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".info")
+}
+
+func (s *Store) dataPath(id string) string {
+	return filepath.Join(s.dir, id+".bin")
+}
+
+// validID 只接受十六进制 ID，防止通过 ID 进行路径遍历
+// validID only accepts hex IDs so the ID cannot be used for path traversal
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, r := range id {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,20 +1,143 @@
 package utility
 
 import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"mime"
+	"net/http"
 	"path/filepath"
 	"strings"
+
+	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
+	"github.com/ShinoharaHaruna/GoFi/internal/storage"
 )
 
-// IsPathSafe 检查目标路径是否在基础目录内
-// IsPathSafe checks if the target path is within the base directory
-func IsPathSafe(targetPath, baseDir string) bool {
-	cleanBaseDir, err := filepath.Abs(baseDir)
+// sniffLen 是 MIME 类型探测所需的字节数
+// sniffLen is the number of bytes needed for MIME type sniffing
+const sniffLen = 512
+
+// ContentInspector 在内容流经时计算 SHA-256 并探测 MIME 类型
+// ContentInspector computes the SHA-256 and sniffs the MIME type while content streams through it
+type ContentInspector struct {
+	io.Reader
+	name   string
+	head   []byte
+	hasher hash.Hash
+}
+
+// NewContentInspector 包装 r；name 用于在内容无法识别时按扩展名推断类型
+// NewContentInspector wraps r; name is used to infer the type from its extension when sniffing is inconclusive
+func NewContentInspector(name string, r io.Reader) *ContentInspector {
+	br := bufio.NewReaderSize(r, sniffLen)
+	head, _ := br.Peek(sniffLen) // 短于 512 字节的内容会返回 EOF / Content shorter than 512 bytes returns EOF
+	hasher := sha256.New()
+	return &ContentInspector{
+		Reader: io.TeeReader(br, hasher),
+		name:   name,
+		head:   append([]byte(nil), head...),
+		hasher: hasher,
+	}
+}
+
+// SHA256 返回已读取内容的十六进制摘要，应在读取完毕后调用
+// SHA256 returns the hex digest of the content read so far; call it after reading everything
+func (ci *ContentInspector) SHA256() string {
+	return hex.EncodeToString(ci.hasher.Sum(nil))
+}
+
+// MimeType 返回探测到的 MIME 类型
+// MimeType returns the detected MIME type
+func (ci *ContentInspector) MimeType() string {
+	return DetectMimeType(ci.name, ci.head)
+}
+
+// DetectMimeType 根据内容探测 MIME 类型，探测结果过于笼统时回退到扩展名
+// DetectMimeType sniffs the MIME type from content, falling back to the extension when sniffing is too generic
+func DetectMimeType(name string, head []byte) string {
+	sniffed := http.DetectContentType(head)
+	if sniffed == "application/octet-stream" || strings.HasPrefix(sniffed, "text/plain") {
+		if byExt := mime.TypeByExtension(filepath.Ext(name)); byExt != "" {
+			return byExt
+		}
+	}
+	return sniffed
+}
+
+// BackfillFileRecords 为存储中已有但没有 File 记录的对象补建记录，并将旧短链接关联到对应文件
+// BackfillFileRecords creates File records for stored objects that have none and links legacy short links to their files
+func BackfillFileRecords(ctx context.Context, store storage.Backend, files *repository.FileRepo, links *repository.ShortLinkRepo) error {
+	created := 0
+	for _, visibility := range []storage.Visibility{storage.VisibilityPublic, storage.VisibilityPrivate} {
+		objects, err := store.List(ctx, visibility)
+		if err != nil {
+			return err
+		}
+
+		known, err := files.Names(string(visibility))
+		if err != nil {
+			return err
+		}
+		knownSet := make(map[string]bool, len(known))
+		for _, name := range known {
+			knownSet[name] = true
+		}
+
+		for _, object := range objects {
+			if knownSet[object.Name] {
+				continue
+			}
+			file, err := inspectStoredObject(ctx, store, object)
+			if err != nil {
+				return err
+			}
+			if err := files.Create(&file); err != nil {
+				return err
+			}
+			created++
+		}
+	}
+
+	// 旧短链接只记录了文件名和隐私状态，据此找到对应的文件
+	// Legacy short links only know the filename and privacy flag; resolve them to files
+	linked, err := links.LinkLegacy()
 	if err != nil {
-		return false
+		return err
+	}
+
+	if created > 0 || linked > 0 {
+		log.Printf("Backfilled %d file records and linked %d legacy short links.", created, linked)
 	}
-	cleanTargetPath, err := filepath.Abs(targetPath)
+	return nil
+}
+
+// inspectStoredObject 读取已存储的对象，计算其 File 记录
+// inspectStoredObject reads a stored object and computes its File record
+func inspectStoredObject(ctx context.Context, store storage.Backend, object storage.ObjectInfo) (models.File, error) {
+	obj, info, err := store.Get(ctx, object.Visibility, object.Name)
 	if err != nil {
-		return false
+		return models.File{}, err
 	}
-	return strings.HasPrefix(cleanTargetPath, cleanBaseDir)
+	defer obj.Close()
+
+	inspector := NewContentInspector(object.Name, obj)
+	if _, err := io.Copy(io.Discard, inspector); err != nil {
+		return models.File{}, err
+	}
+
+	return models.File{
+		Name:         info.Name,
+		Visibility:   string(info.Visibility),
+		OriginalName: info.Name,
+		Size:         info.Size,
+		SHA256:       inspector.SHA256(),
+		MimeType:     inspector.MimeType(),
+		CreatedAt:    info.ModTime.UTC(),
+		UpdatedAt:    info.ModTime.UTC(),
+	}, nil
 }
@@ -0,0 +1,47 @@
+package utility
+
+import "sync"
+
+// nameLock 是带引用计数的互斥锁，无人使用时从表中移除
+// nameLock is a reference-counted mutex that is removed from the table once unused
+type nameLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NameLocks 是文件名锁表，零值即可使用；每个 Server 持有自己的表
+// NameLocks is a table of filename locks whose zero value is ready to use; every Server holds its own
+type NameLocks struct {
+	mu    sync.Mutex
+	locks map[string]*nameLock
+}
+
+// Lock 锁定 visibility/name，串行化对同一文件名的写入；返回解锁函数
+// Lock locks visibility/name so writes to the same filename are serialized; it returns the unlock function
+func (t *NameLocks) Lock(visibility, name string) (unlock func()) {
+	key := visibility + "/" + name
+
+	t.mu.Lock()
+	if t.locks == nil {
+		t.locks = make(map[string]*nameLock)
+	}
+	l, ok := t.locks[key]
+	if !ok {
+		l = &nameLock{}
+		t.locks[key] = l
+	}
+	l.refs++
+	t.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+
+		t.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(t.locks, key)
+		}
+		t.mu.Unlock()
+	}
+}
@@ -0,0 +1,22 @@
+package utility
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// Sign 使用 secret 对 parts 计算 HMAC-SHA256 签名，返回 URL 安全的 base64 字符串
+// Sign computes an HMAC-SHA256 signature of parts with secret and returns it as URL-safe base64
+func Sign(secret string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.Join(parts, "\n")))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature 以常量时间比较 signature 与 parts 的签名
+// VerifySignature compares signature against the signature of parts in constant time
+func VerifySignature(secret, signature string, parts ...string) bool {
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, parts...)))
+}
@@ -4,9 +4,11 @@ import (
 	"crypto/rand"
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"unicode"
+	"unicode/utf8"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/database"
-	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
 )
 
 // GenerateRandomString 生成指定长度的随机十六进制字符串
@@ -38,20 +40,88 @@ func GenerateUUIDv4() (string, error) {
 		hexStr[20:32], nil
 }
 
-// GenerateUniqueShortCode 生成一个在数据库中唯一的短代码
-// GenerateUniqueShortCode generates a short code that is unique in the database
-func GenerateUniqueShortCode(length int) (string, error) {
+// 短代码（包括自定义别名）的长度范围
+// Length bounds of short codes, custom aliases included
+const (
+	MinShortCodeLength = 3
+	MaxShortCodeLength = 64
+)
+
+// 短代码可用的预定义字母表
+// Predefined alphabets for short codes
+const (
+	AlphabetHex    = "0123456789abcdef"
+	AlphabetBase62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	AlphabetBase58 = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz" // 去除了 0OIl 等易混淆字符 / Without look-alikes such as 0OIl
+)
+
+// ResolveAlphabet 将字母表名称（hex、base62、base58）或自定义字符集解析为字母表
+// ResolveAlphabet resolves an alphabet name (hex, base62, base58) or a literal character set into an alphabet
+func ResolveAlphabet(name string) (string, error) {
+	switch name {
+	case "", "hex":
+		return AlphabetHex, nil
+	case "base62":
+		return AlphabetBase62, nil
+	case "base58":
+		return AlphabetBase58, nil
+	}
+
+	seen := make(map[rune]bool)
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return "", fmt.Errorf("short code alphabet may only contain ASCII letters, digits, '-' and '_'")
+		}
+		if seen[r] {
+			return "", fmt.Errorf("short code alphabet contains %q twice", r)
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return "", fmt.Errorf("short code alphabet needs at least 2 characters")
+	}
+	return name, nil
+}
+
+// GenerateRandomCode 生成由 alphabet 中字符组成、长度为 length 的均匀随机字符串
+// GenerateRandomCode generates a uniformly random string of length characters drawn from alphabet
+func GenerateRandomCode(alphabet string, length int) (string, error) {
+	code := make([]byte, length)
+	max := big.NewInt(int64(len(alphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// GenerateUniqueShortCode 生成一个在 links 中唯一的短代码
+// GenerateUniqueShortCode generates a short code that is unique in links
+func GenerateUniqueShortCode(links *repository.ShortLinkRepo, alphabet string, length int) (string, error) {
 	for range 10 { // 尝试 10 次以避免无限循环 / Try 10 times to avoid an infinite loop
-		code, err := GenerateRandomString(length)
+		code, err := GenerateRandomCode(alphabet, length)
 		if err != nil {
 			return "", err
 		}
 
-		var count int64
-		database.DB.Model(&models.ShortLink{}).Where("short_code = ?", code).Count(&count)
-		if count == 0 {
+		if taken, err := links.CodeExists(code); err == nil && !taken {
 			return code, nil
 		}
 	}
 	return "", fmt.Errorf("failed to generate a unique short code after multiple attempts")
 }
+
+// Truncate 将 s 截断到最多 n 个字节，且不拆分 UTF-8 字符
+// Truncate shortens s to at most n bytes without splitting a UTF-8 character
+func Truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
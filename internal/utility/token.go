@@ -1,39 +1,108 @@
 package utility
 
 import (
+	"log"
 	"strings"
+	"time"
 
-	"github.com/ShinoharaHaruna/GoFi/internal/database"
 	"github.com/ShinoharaHaruna/GoFi/internal/models"
+	"github.com/ShinoharaHaruna/GoFi/internal/repository"
 	"github.com/gin-gonic/gin"
 )
 
-// IsTokenValid 检查提供的 token 是否有效
-// IsTokenValid checks if the provided token is valid
-func IsTokenValid(c *gin.Context, keyType models.ApiKeyType) bool {
-	// 1. 按优先级顺序从 Header, Path, Query 中获取 Token
-	// 1. Get Token from Header, Path, Query in order of priority
-	token := ""
-	authHeader := c.GetHeader("Authorization")
-	if after, ok := strings.CutPrefix(authHeader, "Bearer "); ok {
-		token = after
-	} else {
-		token = c.Query("token")
+// apiKeyContextKey 是已验证的 API Key 在 gin 上下文中的键
+// apiKeyContextKey is the gin context key holding the authenticated API key
+const apiKeyContextKey = "api_key"
+
+// apiKeyTouchInterval 是写入 last_used_at 的最小间隔，避免频繁使用的密钥每次请求都写数据库
+// apiKeyTouchInterval is the minimum interval between last_used_at writes, so busy keys do not write to the database on every request
+const apiKeyTouchInterval = time.Minute
+
+// GenerateAPIKey 生成一个新的 API Key，返回完整密钥和用于识别的前缀
+// GenerateAPIKey generates a new API key and returns the full key and its identifying prefix
+func GenerateAPIKey() (key, prefix string, err error) {
+	prefix, err = GenerateRandomString(4)
+	if err != nil {
+		return "", "", err
+	}
+	secret, err := GenerateRandomString(24)
+	if err != nil {
+		return "", "", err
 	}
+	return models.APIKeyMarker + prefix + "_" + secret, prefix, nil
+}
 
+// IsTokenValid 检查提供的 token 是否是 keys 中有效且拥有 scope 权限的密钥，成功时将 API Key 存入上下文
+// IsTokenValid checks if the provided token is a valid key in keys with the scope, storing the API key in the context on success
+func IsTokenValid(c *gin.Context, keys *repository.ApiKeyRepo, scope models.Scope) bool {
+	// 1. 按优先级顺序从 Header, Query 中获取 Token
+	// 1. Get Token from Header, Query in order of priority
+	token := RequestToken(c)
 	if token == "" {
 		return false
 	}
 
 	// 2. 在数据库中查找 Token
 	// 2. Find the Token in the database
-	var apiKey models.ApiKey
-	result := database.DB.Where("key = ? AND type = ?", token, keyType).First(&apiKey)
-	if result.Error != nil {
-		return false // Token 不存在或类型不匹配 / Token does not exist or type mismatch
+	apiKey, err := keys.FindByHash(models.HashAPIKey(token))
+	if err != nil {
+		return false // Token 不存在 / Token does not exist
+	}
+
+	// 3. 检查 Token 是否启用且未过期
+	// 3. Check if the Token is enabled and unexpired
+	now := time.Now()
+	if !apiKey.IsEnabled || apiKey.IsExpired(now) {
+		return false
+	}
+
+	// 4. 检查权限范围
+	// 4. Check the scope
+	if !apiKey.HasScope(scope) {
+		return false
+	}
+
+	// 5. 记录最近使用情况
+	// 5. Record the last use
+	touchAPIKey(keys, &apiKey, c.ClientIP(), now)
+
+	c.Set(apiKeyContextKey, apiKey)
+	return true
+}
+
+// touchAPIKey 更新密钥的最近使用时间和 IP；距上次写入不足 apiKeyTouchInterval 时跳过。
+// 条件更新保证并发请求中只有一个会真正写入。
+// touchAPIKey updates the key's last-used time and IP, skipping when the last write is younger than apiKeyTouchInterval.
+// The conditional update makes sure only one of several concurrent requests actually writes.
+func touchAPIKey(keys *repository.ApiKeyRepo, apiKey *models.ApiKey, clientIP string, now time.Time) {
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < apiKeyTouchInterval {
+		return
 	}
 
-	// 3. 检查 Token 是否启用
-	// 3. Check if the Token is enabled
-	return apiKey.IsEnabled
+	if err := keys.Touch(apiKey.ID, clientIP, now, now.Add(-apiKeyTouchInterval)); err != nil {
+		log.Printf("Failed to record use of API key %d: %v", apiKey.ID, err)
+		return
+	}
+	apiKey.LastUsedAt = &now
+	apiKey.LastUsedIP = clientIP
+}
+
+// RequestToken 返回请求携带的 Token：优先使用 Bearer 认证头，其次是 token 查询参数
+// RequestToken returns the Token carried by the request: the Bearer authorization header first, then the token query parameter
+func RequestToken(c *gin.Context) string {
+	if after, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+		return after
+	}
+	return c.Query("token")
+}
+
+// CurrentAPIKey 返回本次请求中已通过 IsTokenValid 验证的 API Key
+// CurrentAPIKey returns the API key validated by IsTokenValid for this request
+func CurrentAPIKey(c *gin.Context) (models.ApiKey, bool) {
+	value, ok := c.Get(apiKeyContextKey)
+	if !ok {
+		return models.ApiKey{}, false
+	}
+	apiKey, ok := value.(models.ApiKey)
+	return apiKey, ok
 }